@@ -0,0 +1,84 @@
+package pdftotext
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` batch
+// ----------------------------------------------------------------------------
+
+// RunBatch executes prepared `pdftotext` command for each of `paths`, running
+// up to `parallelism` conversions at once, and returns results by path.
+//
+// A failed conversion doesn't stop the others; its error is in the result.
+// When `ctx` is done, no more conversions are started, the running ones are
+// killed, and the context error is returned along with the results.
+func (c *Command) RunBatch(ctx context.Context, paths []string, parallelism int) (map[string]Result, error) {
+	sem := make(chan struct{}, max(parallelism, 1))
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		res = make(map[string]Result, len(paths))
+	)
+
+	for _, path := range paths {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			res[path] = Result{Err: ctx.Err()}
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(path string) {
+			defer func() { <-sem; wg.Done() }()
+
+			r, err := c.RunResult(ctx, path)
+			if r == nil {
+				r = &Result{}
+			}
+			r.Err = err
+
+			mu.Lock()
+			res[path] = *r
+			mu.Unlock()
+		}(path)
+	}
+
+	wg.Wait()
+
+	return res, ctx.Err()
+}
+
+// RunConcat executes prepared `pdftotext` command for each of `paths`, in
+// order, and returns the outputs concatenated as a single text, e.g. of
+// a document split into several files. Outputs are separated by a page break,
+// unless one already ends with it.
+//
+// The first failed conversion stops the others, and its error is returned
+// naming the path, also `ErrTooManyPages` of `WithMaxPagesFast`.
+func (c *Command) RunConcat(ctx context.Context, paths ...string) (io.Reader, error) {
+	var buf bytes.Buffer
+
+	for i, path := range paths {
+		out, err := c.RunBytes(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("pdftotext: convert %s: %w", path, err)
+		}
+
+		buf.Write(out)
+		if i < len(paths)-1 && !bytes.HasSuffix(out, []byte(pageBreak)) {
+			buf.WriteString(pageBreak)
+		}
+	}
+
+	return &buf, nil
+}
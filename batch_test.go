@@ -0,0 +1,81 @@
+package pdftotext
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunConcat(t *testing.T) {
+	f := &fakeRunner{respond: func(args []string) fakeResult {
+		return fakeResult{stdout: strings.TrimSuffix(args[len(args)-2], ".pdf")}
+	}}
+	cmd := newFake(t, f)
+
+	out, err := cmd.RunConcat(context.Background(), "one.pdf", "two.pdf")
+	if err != nil {
+		t.Fatalf("RunConcat() error = %v", err)
+	}
+	if b, _ := io.ReadAll(out); string(b) != "one\ftwo" {
+		t.Errorf("RunConcat() = %q, want %q", b, "one\ftwo")
+	}
+}
+
+func TestRunConcatTooManyPages(t *testing.T) {
+	f := &fakeRunner{respond: func(args []string) fakeResult {
+		if args[len(args)-2] == "long.pdf" {
+			return fakeResult{stdout: "1\f2\f3\f"}
+		}
+		return fakeResult{stdout: "1\f"}
+	}}
+	cmd := newFake(t, f, WithMaxPagesFast(2))
+
+	out, err := cmd.RunConcat(context.Background(), "short.pdf", "long.pdf", "other.pdf")
+	if !errors.Is(err, ErrTooManyPages) {
+		t.Fatalf("RunConcat() error = %v, want ErrTooManyPages", err)
+	}
+	if !strings.Contains(err.Error(), "long.pdf") {
+		t.Errorf("RunConcat() error = %v, want the path", err)
+	}
+	if out != nil {
+		t.Errorf("RunConcat() = %v, want nil", out)
+	}
+
+	// the conversion stops at the failed path
+	if got := f.conversions(); len(got) != 2 {
+		t.Errorf("runs = %q, want 2", got)
+	}
+}
+
+func TestRunBatchErrors(t *testing.T) {
+	f := &fakeRunner{respond: func(args []string) fakeResult {
+		if strings.HasPrefix(args[len(args)-2], "bad") {
+			return fakeResult{stderr: "Syntax Error: broken", code: 1}
+		}
+		return fakeResult{stdout: "text\f"}
+	}}
+	cmd := newFake(t, f, WithUserPassword("s3cret"))
+
+	res, err := cmd.RunBatch(context.Background(), []string{"good.pdf", "bad-1.pdf", "bad-2.pdf"}, 2)
+	if err != nil {
+		t.Fatalf("RunBatch() error = %v", err)
+	}
+
+	if res["good.pdf"].Err != nil {
+		t.Errorf("good.pdf error = %v", res["good.pdf"].Err)
+	}
+
+	// errors name the file, with the password redacted
+	for _, path := range []string{"bad-1.pdf", "bad-2.pdf"} {
+		err := res[path].Err
+		if !errors.Is(err, ErrOpenPDF) {
+			t.Errorf("%s error = %v, want ErrOpenPDF", path, err)
+			continue
+		}
+		if msg := err.Error(); !strings.Contains(msg, "-upw *** "+path+" -") || strings.Contains(msg, "s3cret") {
+			t.Errorf("%s error = %v, want the command converting it", path, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package pdftotext
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` bounding boxes
+// ----------------------------------------------------------------------------
+
+// Word is a word with its bounding box, in points from the top-left corner of
+// the page.
+type Word struct {
+	Text string
+	XMin float64
+	YMin float64
+	XMax float64
+	YMax float64
+	Page int // 1-based page number within the document
+}
+
+// RunBBox executes prepared `pdftotext` command with `-bbox` and returns the
+// words with their bounding boxes.
+//
+// The `-bbox` option is only supported by Poppler, so it fails for Xpdf.
+func (c *Command) RunBBox(ctx context.Context, inpath string) ([]Word, error) {
+	if err := c.require(ctx, requirement{"-bbox", VariantPoppler}); err != nil {
+		return nil, err
+	}
+
+	inpath, err := c.inpath(inpath)
+	if err != nil {
+		return nil, err
+	}
+
+	out, _, err := c.withArgs("-bbox").exec(ctx, inpath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseBBox(out, c.firstPage())
+}
+
+// parseBBox parses words from XHTML produced by `pdftotext -bbox`. Pages are
+// numbered from `first`.
+func parseBBox(out []byte, first int) ([]Word, error) {
+	dec := xml.NewDecoder(bytes.NewReader(out))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+
+	var (
+		words []Word
+		page  = first - 1
+	)
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return words, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch el.Name.Local {
+		case "page":
+			page++
+		case "word":
+			w := Word{Page: page}
+			for _, attr := range el.Attr {
+				v, _ := strconv.ParseFloat(attr.Value, 64)
+				switch attr.Name.Local {
+				case "xMin":
+					w.XMin = v
+				case "yMin":
+					w.YMin = v
+				case "xMax":
+					w.XMax = v
+				case "yMax":
+					w.YMax = v
+				}
+			}
+
+			var text string
+			if err := dec.DecodeElement(&text, &el); err != nil {
+				return nil, err
+			}
+			w.Text = text
+
+			words = append(words, w)
+		}
+	}
+}
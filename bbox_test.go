@@ -0,0 +1,127 @@
+package pdftotext
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// bboxDoc wraps `pages` as in `pdftotext -bbox` output.
+func bboxDoc(pages ...string) string {
+	return `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head>
+<title></title>
+<meta name="Producer" content="Poppler">
+<meta http-equiv="Content-Type" content="text/html; charset=UTF-8"/>
+</head>
+<body>
+<doc>
+` + strings.Join(pages, "\n") + `
+</doc>
+</body>
+</html>
+`
+}
+
+func TestParseBBox(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		first   int
+		want    []Word
+		wantErr bool
+	}{
+		{"empty document", bboxDoc(), 1, nil, false},
+		{"page without words", bboxDoc(`<page width="612.000000" height="792.000000"></page>`), 1, nil, false},
+		{
+			"words",
+			bboxDoc(`<page width="612.000000" height="792.000000">
+    <word xMin="72.000000" yMin="71.000000" xMax="101.500000" yMax="83.250000">Hello</word>
+    <word xMin="104.000000" yMin="71.000000" xMax="140.000000" yMax="83.250000">world</word>
+  </page>`),
+			1,
+			[]Word{
+				{Text: "Hello", XMin: 72, YMin: 71, XMax: 101.5, YMax: 83.25, Page: 1},
+				{Text: "world", XMin: 104, YMin: 71, XMax: 140, YMax: 83.25, Page: 1},
+			},
+			false,
+		},
+		{
+			"pages",
+			bboxDoc(
+				`<page width="612" height="792"><word xMin="1" yMin="2" xMax="3" yMax="4">one</word></page>`,
+				`<page width="612" height="792"></page>`,
+				`<page width="612" height="792"><word xMin="5" yMin="6" xMax="7" yMax="8">three</word></page>`,
+			),
+			1,
+			[]Word{
+				{Text: "one", XMin: 1, YMin: 2, XMax: 3, YMax: 4, Page: 1},
+				{Text: "three", XMin: 5, YMin: 6, XMax: 7, YMax: 8, Page: 3},
+			},
+			false,
+		},
+		{
+			"first page",
+			bboxDoc(`<page width="612" height="792"><word xMin="1" yMin="2" xMax="3" yMax="4">five</word></page>`),
+			5,
+			[]Word{{Text: "five", XMin: 1, YMin: 2, XMax: 3, YMax: 4, Page: 5}},
+			false,
+		},
+		{
+			"entities",
+			bboxDoc(`<page width="612" height="792"><word xMin="1" yMin="2" xMax="3" yMax="4">&lt;a&amp;b&gt;&quot;</word><word xMin="1" yMin="2" xMax="3" yMax="4">&nbsp;</word></page>`),
+			1,
+			[]Word{
+				{Text: `<a&b>"`, XMin: 1, YMin: 2, XMax: 3, YMax: 4, Page: 1},
+				{Text: " ", XMin: 1, YMin: 2, XMax: 3, YMax: 4, Page: 1},
+			},
+			false,
+		},
+		{
+			"invalid coordinates",
+			bboxDoc(`<page width="612" height="792"><word xMin="x" yMin="2" xMax="3" yMax="4">word</word></page>`),
+			1,
+			[]Word{{Text: "word", YMin: 2, XMax: 3, YMax: 4, Page: 1}},
+			false,
+		},
+		{"truncated", `<doc><page width="612" height="792"><word xMin="1" yMin="2" xMax="3" yMax="4">wo`, 1, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBBox([]byte(tt.in), tt.first)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBBox() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBBox() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunBBox(t *testing.T) {
+	out := bboxDoc(`<page width="612" height="792"><word xMin="1" yMin="2" xMax="3" yMax="4">two</word></page>`)
+
+	f := &fakeRunner{respond: output(out)}
+	cmd := newFake(t, f, WithPageRange(2, 2))
+
+	words, err := cmd.RunBBox(context.Background(), "in.pdf")
+	if err != nil {
+		t.Fatalf("RunBBox() error = %v", err)
+	}
+	if want := []Word{{Text: "two", XMin: 1, YMin: 2, XMax: 3, YMax: 4, Page: 2}}; !reflect.DeepEqual(words, want) {
+		t.Errorf("RunBBox() = %+v, want %+v", words, want)
+	}
+	if got := f.conversions(); len(got) != 1 || !slices.Contains(got[0], "-bbox") {
+		t.Errorf("runs = %q, want one with -bbox", got)
+	}
+
+	xpdf := newFake(t, &fakeRunner{banner: xpdfBanner})
+	if _, err := xpdf.RunBBox(context.Background(), "in.pdf"); err == nil || !strings.Contains(err.Error(), "-bbox is supported only by Poppler") {
+		t.Errorf("RunBBox() error = %v, want unsupported by Xpdf", err)
+	}
+}
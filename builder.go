@@ -0,0 +1,167 @@
+package pdftotext
+
+import "time"
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` builder
+// ----------------------------------------------------------------------------
+
+// Builder builds a command with method chaining, as an alternative to passing
+// options to `NewCommand`. Each method applies the option of the same name,
+// e.g. `Layout` applies `WithModeLayout`, so both build the same command.
+//
+//	cmd, err := pdftotext.NewBuilder().Layout().PageRange(1, 5).Build()
+type Builder struct {
+	opts []option
+}
+
+// NewBuilder creates new Builder of a command with no options.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// With applies `opts`, e.g. ones without a dedicated method.
+func (b *Builder) With(opts ...option) *Builder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// Build creates the command, validated as in `NewCommand`.
+func (b *Builder) Build() (*Command, error) {
+	return NewCommand(b.opts...)
+}
+
+// CustomPath applies `WithCustomPath`.
+func (b *Builder) CustomPath(path string) *Builder {
+	return b.With(WithCustomPath(path))
+}
+
+// WorkingDir applies `WithWorkingDir`.
+func (b *Builder) WorkingDir(dir string) *Builder {
+	return b.With(WithWorkingDir(dir))
+}
+
+// CustomConfig applies `WithCustomConfig`.
+func (b *Builder) CustomConfig(path string) *Builder {
+	return b.With(WithCustomConfig(path))
+}
+
+// PageFrom applies `WithPageFrom`.
+func (b *Builder) PageFrom(page uint64) *Builder {
+	return b.With(WithPageFrom(page))
+}
+
+// PageTo applies `WithPageTo`.
+func (b *Builder) PageTo(page uint64) *Builder {
+	return b.With(WithPageTo(page))
+}
+
+// PageRange applies `WithPageRange`.
+func (b *Builder) PageRange(from, to uint64) *Builder {
+	return b.With(WithPageRange(from, to))
+}
+
+// Mode applies `WithMode`.
+func (b *Builder) Mode(mode Mode) *Builder {
+	return b.With(WithMode(mode))
+}
+
+// Layout applies `WithModeLayout`.
+func (b *Builder) Layout() *Builder {
+	return b.With(WithModeLayout())
+}
+
+// Simple applies `WithModeSimple`.
+func (b *Builder) Simple() *Builder {
+	return b.With(WithModeSimple())
+}
+
+// Simple2 applies `WithModeSimple2`.
+func (b *Builder) Simple2() *Builder {
+	return b.With(WithModeSimple2())
+}
+
+// Table applies `WithModeTable`.
+func (b *Builder) Table() *Builder {
+	return b.With(WithModeTable())
+}
+
+// LinePrinter applies `WithModeLinePrinter`.
+func (b *Builder) LinePrinter() *Builder {
+	return b.With(WithModeLinePrinter())
+}
+
+// Raw applies `WithModeRaw`.
+func (b *Builder) Raw() *Builder {
+	return b.With(WithModeRaw())
+}
+
+// CharFixedWidth applies `WithCharFixedWidth`.
+func (b *Builder) CharFixedWidth(width uint64) *Builder {
+	return b.With(WithCharFixedWidth(width))
+}
+
+// LineFixedSpacing applies `WithLineFixedSpacing`.
+func (b *Builder) LineFixedSpacing(spacing uint64) *Builder {
+	return b.With(WithLineFixedSpacing(spacing))
+}
+
+// Encoding applies `WithEncoding`.
+func (b *Builder) Encoding(name string) *Builder {
+	return b.With(WithEncoding(name))
+}
+
+// EndOfLine applies `WithEndOfLine`.
+func (b *Builder) EndOfLine(kind EOL) *Builder {
+	return b.With(WithEndOfLine(kind))
+}
+
+// NoPageBreak applies `WithNoPageBreak`.
+func (b *Builder) NoPageBreak() *Builder {
+	return b.With(WithNoPageBreak())
+}
+
+// ByteOrderMarker applies `WithByteOrderMarker`.
+func (b *Builder) ByteOrderMarker() *Builder {
+	return b.With(WithByteOrderMarker())
+}
+
+// Margin applies `WithMargin`.
+func (b *Builder) Margin(t, r, bottom, l uint64) *Builder {
+	return b.With(WithMargin(t, r, bottom, l))
+}
+
+// Quiet applies `WithQuiet`.
+func (b *Builder) Quiet() *Builder {
+	return b.With(WithQuiet())
+}
+
+// OwnerPassword applies `WithOwnerPassword`.
+func (b *Builder) OwnerPassword(password string) *Builder {
+	return b.With(WithOwnerPassword(password))
+}
+
+// UserPassword applies `WithUserPassword`.
+func (b *Builder) UserPassword(password string) *Builder {
+	return b.With(WithUserPassword(password))
+}
+
+// Transforms applies `WithTransforms`.
+func (b *Builder) Transforms(transforms ...Transform) *Builder {
+	return b.With(WithTransforms(transforms...))
+}
+
+// StrictFonts applies `WithStrictFonts`.
+func (b *Builder) StrictFonts() *Builder {
+	return b.With(WithStrictFonts())
+}
+
+// Timeout applies `WithTimeout`.
+func (b *Builder) Timeout(d time.Duration) *Builder {
+	return b.With(WithTimeout(d))
+}
+
+// Runner applies `WithRunner`.
+func (b *Builder) Runner(r Runner) *Builder {
+	return b.With(WithRunner(r))
+}
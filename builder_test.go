@@ -0,0 +1,86 @@
+package pdftotext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *Builder
+		opts    []option
+		banner  string
+	}{
+		{"empty", NewBuilder(), nil, ""},
+		{
+			"layout",
+			NewBuilder().Layout().PageRange(2, 5).Encoding("UTF-8").Margin(1, 2, 3, 4),
+			[]option{WithModeLayout(), WithPageRange(2, 5), WithEncoding("UTF-8"), WithMargin(1, 2, 3, 4)},
+			"",
+		},
+		{
+			"line printer",
+			NewBuilder().LinePrinter().CharFixedWidth(3).LineFixedSpacing(2).EndOfLine(EOLDOS).Quiet(),
+			[]option{WithModeLinePrinter(), WithCharFixedWidth(3), WithLineFixedSpacing(2), WithEndOfLine(EOLDOS), WithQuiet()},
+			xpdfBanner,
+		},
+		{
+			"simple2",
+			NewBuilder().Simple2().PageRange(1, 2),
+			[]option{WithModeSimple2(), WithPageRange(1, 2)},
+			xpdfBanner,
+		},
+		{
+			"pages and passwords",
+			NewBuilder().PageFrom(2).PageTo(3).Raw().NoPageBreak().ByteOrderMarker().OwnerPassword("a").UserPassword("b"),
+			[]option{WithPageFrom(2), WithPageTo(3), WithModeRaw(), WithNoPageBreak(), WithByteOrderMarker(), WithOwnerPassword("a"), WithUserPassword("b")},
+			"",
+		},
+		{
+			"mode and options",
+			NewBuilder().Mode(ModeTable).CustomConfig("x.cfg").With(WithArgs("-nodiag"), WithCropBox()),
+			[]option{WithMode(ModeTable), WithCustomConfig("x.cfg"), WithArgs("-nodiag"), WithCropBox()},
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{banner: tt.banner}
+
+			built, err := tt.builder.Runner(f).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			cmd := newFake(t, f, tt.opts...)
+			if got, want := built.Args("in.pdf"), cmd.Args("in.pdf"); !slices.Equal(got, want) {
+				t.Errorf("Build() args = %q, options args %q", got, want)
+			}
+			if built.String() != cmd.String() {
+				t.Errorf("Build() = %s, options %s", built, cmd)
+			}
+		})
+	}
+}
+
+func TestBuilderValidation(t *testing.T) {
+	poppler := func() *Builder { return NewBuilder().Runner(&fakeRunner{}) }
+
+	builders := map[string]*Builder{
+		"page range":      poppler().PageRange(5, 2),
+		"conflicting":     poppler().Layout().Raw(),
+		"fixed with raw":  poppler().Raw().CharFixedWidth(3),
+		"unknown mode":    poppler().Mode("fancy"),
+		"line spacing":    poppler().Layout().LineFixedSpacing(2),
+		"zero first page": poppler().PageFrom(0),
+		"Xpdf cropbox":    NewBuilder().Runner(&fakeRunner{banner: xpdfBanner}).With(WithCropBox()),
+	}
+
+	for name, b := range builders {
+		if _, err := b.Build(); err == nil {
+			t.Errorf("%s: Build() error = nil, want error", name)
+		}
+	}
+}
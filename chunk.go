@@ -0,0 +1,161 @@
+package pdftotext
+
+import (
+	"context"
+	"errors"
+	"unicode"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` chunks
+// ----------------------------------------------------------------------------
+
+// Chunk is a piece of the output of roughly fixed size.
+type Chunk struct {
+	Text string
+
+	// StartPage and EndPage are the pages the chunk spans, numbered as in the
+	// document.
+	StartPage int
+	EndPage   int
+
+	// Start and End are character offsets of the chunk within the output. Page
+	// breaks count as a single character, as they are replaced with a newline
+	// in chunks spanning pages.
+	Start int
+	End   int
+}
+
+// Chunks executes prepared `pdftotext` command and splits the output into
+// chunks of roughly `targetChars` characters, with consecutive chunks sharing
+// roughly `overlapChars` characters.
+//
+// Chunks are cut at whitespace, so words are never split. A chunk is longer
+// than `targetChars` only if it contains a single longer word. The output is
+// processed page by page, so memory is bounded by the chunk and page size.
+func (c *Command) Chunks(ctx context.Context, inpath string, targetChars, overlapChars int) ([]Chunk, error) {
+	if targetChars < 1 {
+		return nil, errors.New("pdftotext: chunk size must be at least 1")
+	}
+	if overlapChars < 0 || overlapChars >= targetChars {
+		return nil, errors.New("pdftotext: chunk overlap must be between 0 and chunk size")
+	}
+
+	ch := &chunker{target: targetChars, overlap: overlapChars}
+
+	err := c.eachPage(ctx, inpath, func(page Page) error {
+		// the page break keeps the last word of the page apart from the next
+		ch.write(page.Text+"\n", page.Number)
+		return nil
+	})
+	if failed(err) {
+		return nil, err
+	}
+
+	ch.flush()
+
+	return ch.chunks, err
+}
+
+type chunker struct {
+	target  int
+	overlap int
+
+	text  []rune
+	pages []int // page of each character in text
+
+	offset  int // offset of text within the output
+	emitted int // offset of the end of the last chunk
+	chunks  []Chunk
+}
+
+func (c *chunker) write(page string, num int) {
+	for _, r := range page {
+		// a chunk never starts with whitespace
+		if len(c.text) == 0 && c.offset >= c.emitted && unicode.IsSpace(r) {
+			c.offset++
+			continue
+		}
+
+		c.text = append(c.text, r)
+		c.pages = append(c.pages, num)
+	}
+
+	for len(c.text) > c.target {
+		end := c.cut()
+		if end < 0 {
+			return // wait for the end of the word
+		}
+
+		c.emit(end)
+	}
+}
+
+// flush emits the rest of the text, unless it's all part of the last chunk.
+func (c *chunker) flush() {
+	if len(c.text) > 0 {
+		c.emit(len(c.text))
+	}
+}
+
+// cut returns the end of the next chunk: the last word boundary within the
+// target size, or the first one after it. The chunk always extends past the
+// end of the previous one.
+func (c *chunker) cut() int {
+	for i := c.target; i > max(c.emitted-c.offset, 0); i-- {
+		if unicode.IsSpace(c.text[i]) {
+			return i
+		}
+	}
+	for i := c.target + 1; i < len(c.text); i++ {
+		if unicode.IsSpace(c.text[i]) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (c *chunker) emit(end int) {
+	// a chunk never ends with whitespace either
+	next := end
+	for end > 0 && unicode.IsSpace(c.text[end-1]) {
+		end--
+	}
+
+	// whitespace only past the end of the last chunk is skipped
+	if c.offset+end <= c.emitted {
+		c.drop(next)
+		return
+	}
+
+	c.chunks = append(c.chunks, Chunk{
+		Text:      string(c.text[:end]),
+		StartPage: c.pages[0],
+		EndPage:   c.pages[end-1],
+		Start:     c.offset,
+		End:       c.offset + end,
+	})
+	c.emitted = c.offset + end
+
+	// start the next chunk at the beginning of a word within the overlap
+	next = end
+	if c.overlap > 0 {
+		next = max(end-c.overlap, 1)
+		for next < end && !unicode.IsSpace(c.text[next-1]) {
+			next++
+		}
+	}
+	c.drop(next)
+}
+
+// drop drops the text before `next`, and whitespace following it.
+func (c *chunker) drop(next int) {
+	for next < len(c.text) && unicode.IsSpace(c.text[next]) {
+		next++
+	}
+
+	c.text = append(c.text[:0], c.text[next:]...)
+	c.pages = append(c.pages[:0], c.pages[next:]...)
+	c.offset += next
+}
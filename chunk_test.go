@@ -0,0 +1,118 @@
+package pdftotext
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestChunks(t *testing.T) {
+	pages := "page one text\n\fpage two\n\fpage three words here\n\f"
+
+	tests := []struct {
+		name    string
+		output  string
+		target  int
+		overlap int
+		want    []Chunk
+	}{
+		{
+			name:   "without overlap",
+			output: pages,
+			target: 10,
+			want: []Chunk{
+				{Text: "page one", StartPage: 1, EndPage: 1, Start: 0, End: 8},
+				{Text: "text\n\npage", StartPage: 1, EndPage: 2, Start: 9, End: 19},
+				{Text: "two\n\npage", StartPage: 2, EndPage: 3, Start: 20, End: 29},
+				{Text: "three", StartPage: 3, EndPage: 3, Start: 30, End: 35},
+				{Text: "words here", StartPage: 3, EndPage: 3, Start: 36, End: 46},
+			},
+		},
+		{
+			name:    "with overlap",
+			output:  pages,
+			target:  10,
+			overlap: 3,
+			want: []Chunk{
+				{Text: "page one", StartPage: 1, EndPage: 1, Start: 0, End: 8},
+				{Text: "one text", StartPage: 1, EndPage: 1, Start: 5, End: 13},
+				{Text: "page two", StartPage: 2, EndPage: 2, Start: 15, End: 23},
+				{Text: "two\n\npage", StartPage: 2, EndPage: 3, Start: 20, End: 29},
+				{Text: "three", StartPage: 3, EndPage: 3, Start: 30, End: 35},
+				{Text: "words here", StartPage: 3, EndPage: 3, Start: 36, End: 46},
+			},
+		},
+		{
+			name:   "trailing whitespace",
+			output: "one two\n\n\f  \n\f",
+			target: 10,
+			want: []Chunk{
+				{Text: "one two", StartPage: 1, EndPage: 1, Start: 0, End: 7},
+			},
+		},
+		{
+			name:   "whitespace only",
+			output: " \n\f\n\f",
+			target: 10,
+		},
+		{
+			name:   "page boundary",
+			output: "gamma delta\fepsilon\f",
+			target: 13,
+			want: []Chunk{
+				{Text: "gamma delta", StartPage: 1, EndPage: 1, Start: 0, End: 11},
+				{Text: "epsilon", StartPage: 2, EndPage: 2, Start: 12, End: 19},
+			},
+		},
+		{
+			name:   "page boundary within chunk",
+			output: "delta\fepsilon\f",
+			target: 20,
+			want: []Chunk{
+				{Text: "delta\nepsilon", StartPage: 1, EndPage: 2, Start: 0, End: 13},
+			},
+		},
+		{
+			name:   "long word",
+			output: "abcdefghijklmno pq\f",
+			target: 5,
+			want: []Chunk{
+				{Text: "abcdefghijklmno", StartPage: 1, EndPage: 1, Start: 0, End: 15},
+				{Text: "pq", StartPage: 1, EndPage: 1, Start: 16, End: 18},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newFake(t, &fakeRunner{respond: output(tt.output)})
+
+			got, err := cmd.Chunks(context.Background(), "in.pdf", tt.target, tt.overlap)
+			if err != nil {
+				t.Fatalf("Chunks() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Chunks() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+
+			// offsets point into the output with page breaks as newlines
+			text := []rune(strings.ReplaceAll(tt.output, "\f", "\n"))
+			for _, ch := range got {
+				if s := string(text[ch.Start:ch.End]); s != ch.Text {
+					t.Errorf("output[%d:%d] = %q, want %q", ch.Start, ch.End, s, ch.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestChunksInvalid(t *testing.T) {
+	cmd := newFake(t, &fakeRunner{})
+
+	for _, size := range [][2]int{{0, 0}, {10, -1}, {10, 10}} {
+		if _, err := cmd.Chunks(context.Background(), "in.pdf", size[0], size[1]); err == nil {
+			t.Errorf("Chunks(%d, %d) error = nil, want error", size[0], size[1])
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package pdftotext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` config
+// ----------------------------------------------------------------------------
+
+// lazyArg is an argument whose value is produced for each execution, e.g. a
+// path to a temporary file.
+type lazyArg struct {
+	index int // index in args
+
+	// value returns the argument value, and an optional cleanup called once
+	// the execution is finished.
+	value func(ctx context.Context) (string, func(), error)
+}
+
+// prepare returns operands as in `operands`, with lazy arguments produced.
+// The returned cleanup must be called once the execution is finished.
+func (c *Command) prepare(ctx context.Context, inpath string) ([]string, func(), error) {
+	args := c.operands(inpath)
+
+	var cleanups []func()
+	cleanup := func() {
+		for _, fn := range cleanups {
+			fn()
+		}
+	}
+
+	for _, arg := range c.lazy {
+		val, fn, err := arg.value(ctx)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+
+		args[arg.index] = val
+		if fn != nil {
+			cleanups = append(cleanups, fn)
+		}
+	}
+
+	return args, cleanup, nil
+}
+
+// Read config-file from `r`, in place of ~/.xpdfrc or the system-wide config
+// file.
+//
+// The contents are read once, on first execution, and written to a temporary
+// file for each execution, removed as soon as it's finished, also on failure
+// or cancellation. An error reading `r` is returned from each execution.
+func WithConfigReader(r io.Reader) option {
+	var (
+		once sync.Once
+		data []byte
+		err  error
+	)
+
+	return func(c *Command) {
+		i := c.flag("-cfg", "<config>")
+		if c.config == i {
+			c.config = 0 // replaced, it's no longer a path
+		}
+		c.lazy = append(c.lazy, lazyArg{
+			index: i,
+			value: func(context.Context) (string, func(), error) {
+				once.Do(func() { data, err = io.ReadAll(r) })
+				if err != nil {
+					return "", nil, err
+				}
+
+				return tempFile("pdftotext-*.xpdfrc", data)
+			},
+		})
+	}
+}
+
+// Read config-file made of `lines`, e.g. "textEncoding UTF-8", in place of
+// ~/.xpdfrc or the system-wide config file. See xpdfrc(5) for directives.
+//
+// The lines are written in order to a temporary file for each execution, as
+// with `WithConfigReader`. A line containing a line break makes `NewCommand`
+// fail.
+func WithConfigDirectives(lines []string) option {
+	var data strings.Builder
+	for _, line := range lines {
+		data.WriteString(line)
+		data.WriteByte('\n')
+	}
+
+	cfg := WithConfigReader(strings.NewReader(data.String()))
+
+	return func(c *Command) {
+		for _, line := range lines {
+			if strings.ContainsAny(line, "\r\n") {
+				c.errs = append(c.errs, fmt.Errorf("pdftotext: config directive %q spans multiple lines", line))
+			}
+		}
+
+		cfg(c)
+	}
+}
+
+// tempFile writes `data` to a new temporary file, and returns its path and a
+// cleanup removing it.
+func tempFile(pattern string, data []byte) (string, func(), error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", nil, err
+	}
+
+	_, err = f.Write(data)
+	err = errors.Join(err, f.Close())
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", nil, err
+	}
+
+	return f.Name(), func() { _ = os.Remove(f.Name()) }, nil
+}
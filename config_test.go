@@ -0,0 +1,144 @@
+package pdftotext
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// configRunner returns a fake reading the `-cfg` file during the run, into
+// `cfg`, and storing its path in `path`. The run fails with `code`.
+func configRunner(t *testing.T, path, cfg *string, code int) *fakeRunner {
+	return &fakeRunner{respond: func(args []string) fakeResult {
+		*path = flagValue(args, "-cfg")
+
+		data, err := os.ReadFile(*path)
+		if err != nil {
+			t.Errorf("reading config during the run: %v", err)
+		}
+		*cfg = string(data)
+
+		return fakeResult{stdout: "text\f", code: code}
+	}}
+}
+
+func TestWithConfigDirectives(t *testing.T) {
+	tests := []struct {
+		name string
+		code int
+	}{
+		{"success", 0},
+		{"failure", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, cfg string
+			f := configRunner(t, &path, &cfg, tt.code)
+			cmd := newFake(t, f, WithConfigDirectives([]string{"textEncoding UTF-8", "textEOL unix", "textPageBreaks no"}))
+
+			_, err := cmd.Run(context.Background(), "in.pdf")
+			if tt.code == 0 && err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if tt.code != 0 && !errors.Is(err, ErrOpenPDF) {
+				t.Fatalf("Run() error = %v, want ErrOpenPDF", err)
+			}
+
+			// directives are written in order
+			if want := "textEncoding UTF-8\ntextEOL unix\ntextPageBreaks no\n"; cfg != want {
+				t.Errorf("config = %q, want %q", cfg, want)
+			}
+
+			// removed once the run is finished, also on failure
+			if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("config %s not removed: %v", path, err)
+			}
+		})
+	}
+}
+
+func TestWithConfigDirectivesInvalid(t *testing.T) {
+	_, err := NewCommand(WithRunner(&fakeRunner{}), WithConfigDirectives([]string{"textEncoding UTF-8\ntextEOL unix"}))
+	if err == nil {
+		t.Fatal("NewCommand() error = nil, want error")
+	}
+}
+
+func TestWithConfigReaderTimeout(t *testing.T) {
+	runs := map[string]func(cmd *Command) error{
+		"Run": func(cmd *Command) error {
+			_, err := cmd.Run(context.Background(), "in.pdf")
+			return err
+		},
+		"RunTo": func(cmd *Command) error {
+			return cmd.RunTo(context.Background(), "in.pdf", io.Discard)
+		},
+		"RunStream": func(cmd *Command) error {
+			r, err := cmd.RunStream(context.Background(), "in.pdf")
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+
+			_, err = io.ReadAll(r)
+			return err
+		},
+	}
+
+	for name, run := range runs {
+		t.Run(name, func(t *testing.T) {
+			tmp := t.TempDir()
+			t.Setenv("TMPDIR", tmp)
+
+			// the conversion hangs until it's killed
+			runner := RunnerFunc(func(ctx context.Context, cmd *exec.Cmd) error {
+				if _, err := os.Stat(flagValue(cmd.Args, "-cfg")); err != nil {
+					t.Errorf("config missing during the run: %v", err)
+				}
+
+				<-ctx.Done()
+				return ctx.Err()
+			})
+
+			cmd, err := NewCommand(WithRunner(runner), WithTimeout(10*time.Millisecond), WithConfigReader(strings.NewReader("textEncoding UTF-8\n")))
+			if err != nil {
+				t.Fatalf("NewCommand() error = %v", err)
+			}
+
+			if err := run(cmd); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("%s() error = %v, want context.DeadlineExceeded", name, err)
+			}
+
+			if entries, _ := os.ReadDir(tmp); len(entries) > 0 {
+				t.Errorf("temporary files left: %v", entries)
+			}
+		})
+	}
+}
+
+func TestWithConfigReaderStreamClosed(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	cmd := newFake(t, &fakeRunner{respond: output(strings.Repeat("text\f", 100))}, WithConfigReader(strings.NewReader("textEncoding UTF-8\n")))
+
+	r, err := cmd.RunStream(context.Background(), "in.pdf")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+
+	// closed before reading to the end
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if entries, _ := os.ReadDir(tmp); len(entries) > 0 {
+		t.Errorf("temporary files left: %v", entries)
+	}
+}
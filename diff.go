@@ -0,0 +1,220 @@
+package pdftotext
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` diff
+// ----------------------------------------------------------------------------
+
+// diffContext is the number of unchanged lines around changes in a diff.
+const diffContext = 3
+
+// Diff executes prepared `pdftotext` command for PDF files at `a` and `b`, and
+// compares their text line by line, e.g. in regression tests of generated
+// documents. Both are converted with the same options, and page breaks are
+// compared as line breaks.
+//
+// If the text differs, `diff` is a unified diff of it, from `a` to `b`. It's
+// computed in memory, in time proportional to the number of lines times the
+// number of differing ones, and memory proportional to the square of the
+// latter, so it's meant for documents that mostly match.
+func (c *Command) Diff(ctx context.Context, a, b string) (same bool, diff string, err error) {
+	ta, err := c.RunString(ctx, a)
+	if err != nil {
+		return false, "", err
+	}
+	tb, err := c.RunString(ctx, b)
+	if err != nil {
+		return false, "", err
+	}
+
+	la, lb := diffLines(ta), diffLines(tb)
+	if strings.Join(la, "\n") == strings.Join(lb, "\n") {
+		return true, "", nil
+	}
+
+	return false, unifiedDiff(a, b, la, lb), nil
+}
+
+// diffLines splits `text` into lines, with page breaks as line breaks, and
+// trailing empty lines dropped.
+func diffLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, pageBreak, "\n")
+
+	return strings.Split(strings.TrimRight(text, "\n"), "\n")
+}
+
+// diffOp is a line of a diff: ' ' unchanged, '-' removed or '+' added.
+type diffOp struct {
+	kind byte
+	line string
+	a, b int // line indexes in `a` and `b` before this line
+}
+
+// unifiedDiff returns a unified diff from lines `a` to lines `b`.
+func unifiedDiff(namea, nameb string, a, b []string) string {
+	ops := diffOps(a, b)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", namea, nameb)
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// extend the hunk while changes are close enough to share context
+		start, end := max(i-diffContext, 0), i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end = min(end+diffContext, len(ops))
+
+		na, nb := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				na++
+			}
+			if op.kind != '-' {
+				nb++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(ops[start].a, na), hunkRange(ops[start].b, nb))
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			sb.WriteByte('\n')
+		}
+
+		i = end
+	}
+
+	return sb.String()
+}
+
+// hunkRange formats the range of `n` lines after line index `i` of a hunk.
+func hunkRange(i, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", i)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", i+1)
+	}
+
+	return fmt.Sprintf("%d,%d", i+1, n)
+}
+
+// diffOps returns the shortest edit script from lines `a` to lines `b`, found
+// with the Myers algorithm in time proportional to the number of lines times
+// the number of differing ones, D, and memory proportional to D squared.
+func diffOps(a, b []string) []diffOp {
+	// common prefix and suffix don't need the search
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for k := 0; k < pre; k++ {
+		ops = append(ops, diffOp{' ', a[k], k, k})
+	}
+
+	ops = append(ops, myers(a[pre:len(a)-suf], b[pre:len(b)-suf], pre)...)
+
+	for k := 0; k < suf; k++ {
+		ops = append(ops, diffOp{' ', a[len(a)-suf+k], len(a) - suf + k, len(b) - suf + k})
+	}
+
+	return ops
+}
+
+// myers returns the shortest edit script from lines `a` to lines `b`, with
+// line indexes offset by `off`.
+func myers(a, b []string, off int) []diffOp {
+	n, m := len(a), len(b)
+
+	// v[k] is the furthest x reached on diagonal k = x - y, and trace[d] is v
+	// after d edits, for k from -d to d
+	v := make([]int, 2*(n+m)+3)
+	mid := n + m + 1
+	var trace [][]int
+
+	for d := 0; ; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[mid+k-1] < v[mid+k+1]) {
+				x = v[mid+k+1] // down, adding a line of `b`
+			} else {
+				x = v[mid+k-1] + 1 // right, removing a line of `a`
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[mid+k] = x
+
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v[mid-d:mid+d+1]...))
+				return myersPath(a, b, off, trace)
+			}
+		}
+
+		trace = append(trace, append([]int(nil), v[mid-d:mid+d+1]...))
+	}
+}
+
+// myersPath walks `trace` of `myers` back from the ends of `a` and `b`, and
+// returns the edit script.
+func myersPath(a, b []string, off int, trace [][]int) []diffOp {
+	var ops []diffOp
+
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		// the end of the previous edit, or the start
+		k := x - y
+		px, py := 0, 0
+		if d > 0 {
+			prev := trace[d-1] // v for k from -(d-1) to d-1
+			pk := k - 1
+			if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+				pk = k + 1
+			}
+			px = prev[pk+d-1]
+			py = px - pk
+		}
+
+		for x > px && y > py {
+			ops = append(ops, diffOp{' ', a[x-1], off + x - 1, off + y - 1})
+			x, y = x-1, y-1
+		}
+
+		if d > 0 {
+			if x == px {
+				ops = append(ops, diffOp{'+', b[y-1], off + x, off + y - 1})
+			} else {
+				ops = append(ops, diffOp{'-', a[x-1], off + x - 1, off + y})
+			}
+		}
+		x, y = px, py
+	}
+
+	slices.Reverse(ops)
+
+	return ops
+}
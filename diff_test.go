@@ -0,0 +1,182 @@
+package pdftotext
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// lcsLen returns the length of the longest common subsequence of `a` and `b`.
+func lcsLen(a, b []string) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// checkOps fails unless `ops` is a shortest edit script from `a` to `b`.
+func checkOps(t *testing.T, a, b []string, ops []diffOp) {
+	t.Helper()
+
+	var ga, gb []string
+	edits := 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			if op.a != len(ga) {
+				t.Fatalf("op %q at a %d, want %d", op.line, op.a, len(ga))
+			}
+			ga = append(ga, op.line)
+		}
+		if op.kind != '-' {
+			if op.b != len(gb) {
+				t.Fatalf("op %q at b %d, want %d", op.line, op.b, len(gb))
+			}
+			gb = append(gb, op.line)
+		}
+		if op.kind != ' ' {
+			edits++
+		}
+	}
+
+	if !slices.Equal(ga, a) || !slices.Equal(gb, b) {
+		t.Fatalf("ops produce %q -> %q, want %q -> %q", ga, gb, a, b)
+	}
+	if want := len(a) + len(b) - 2*lcsLen(a, b); edits != want {
+		t.Fatalf("ops of %q -> %q have %d edits, want %d", a, b, edits, want)
+	}
+}
+
+func TestDiffOps(t *testing.T) {
+	tests := [][2][]string{
+		{nil, nil},
+		{{"a"}, nil},
+		{nil, {"a"}},
+		{{"a", "b", "c"}, {"a", "b", "c"}},
+		{{"a", "b", "c"}, {"a", "x", "c"}},
+		{{"a", "b", "c", "a", "b", "b", "a"}, {"c", "b", "a", "b", "a", "c"}},
+		{{"x", "y"}, {"y", "x"}},
+	}
+	for _, tt := range tests {
+		checkOps(t, tt[0], tt[1], diffOps(tt[0], tt[1]))
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	lines := func() []string {
+		l := make([]string, rnd.Intn(30))
+		for i := range l {
+			l[i] = string(rune('a' + rnd.Intn(4)))
+		}
+		return l
+	}
+	for i := 0; i < 500; i++ {
+		a, b := lines(), lines()
+		checkOps(t, a, b, diffOps(a, b))
+	}
+}
+
+func TestDiffOpsLarge(t *testing.T) {
+	a := make([]string, 200000)
+	for i := range a {
+		a[i] = fmt.Sprint("line ", i)
+	}
+	b := slices.Clone(a)
+	b[1000] = "changed"
+	b = slices.Delete(b, 50000, 50010)
+	b = slices.Insert(b, 150000, "added", "lines")
+
+	ops := diffOps(a, b)
+
+	edits := 0
+	for _, op := range ops {
+		if op.kind != ' ' {
+			edits++
+		}
+	}
+	if edits != 14 {
+		t.Errorf("diffOps() has %d edits, want 14", edits)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	f := &fakeRunner{respond: func(args []string) fakeResult {
+		if args[len(args)-2] == "a.pdf" {
+			return fakeResult{stdout: "one\ntwo\nthree\fFour\nfive\nsix\nseven\neight\nnine\nten\f"}
+		}
+		return fakeResult{stdout: "one\r\ntwo\r\nthree\ffour\nfive\nsix\nseven\neight\nnine\nten\neleven\f"}
+	}}
+	cmd := newFake(t, f)
+
+	same, diff, err := cmd.Diff(context.Background(), "a.pdf", "b.pdf")
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	want := strings.Join([]string{
+		"--- a.pdf",
+		"+++ b.pdf",
+		"@@ -1,10 +1,11 @@",
+		" one",
+		" two",
+		" three",
+		"-Four",
+		"+four",
+		" five",
+		" six",
+		" seven",
+		" eight",
+		" nine",
+		" ten",
+		"+eleven",
+		"",
+	}, "\n")
+	if same || diff != want {
+		t.Errorf("Diff() = %t, %q, want false, %q", same, diff, want)
+	}
+
+	same, diff, err = cmd.Diff(context.Background(), "a.pdf", "a.pdf")
+	if err != nil || !same || diff != "" {
+		t.Errorf("Diff() of the same file = %t, %q, %v, want true", same, diff, err)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	seq := func(from, to int) []string {
+		var l []string
+		for i := from; i <= to; i++ {
+			l = append(l, fmt.Sprint(i))
+		}
+		return l
+	}
+
+	changed := seq(1, 20)
+	changed[1], changed[17] = "two", "eighteen"
+
+	tests := []struct {
+		name string
+		a, b []string
+		want string
+	}{
+		{"hunks", seq(1, 20), changed, "@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n"},
+		{"removed", seq(1, 5), seq(1, 3), "@@ -1,5 +1,3 @@\n 1\n 2\n 3\n-4\n-5\n"},
+		{"added", nil, seq(1, 3), "@@ -0,0 +1,3 @@\n+1\n+2\n+3\n"},
+	}
+
+	for _, tt := range tests {
+		// as with GNU diff -u
+		if got := unifiedDiff("a", "b", tt.a, tt.b); got != "--- a\n+++ b\n"+tt.want {
+			t.Errorf("%s: unifiedDiff() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package pdftotext
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` encodings
+// ----------------------------------------------------------------------------
+
+// ListEncodings returns names of text encodings supported by `pdftotext`
+// executable at `path`, e.g. to validate `WithEncoding`. If `path` is empty,
+// the executable is searched for as in `NewCommand`.
+func ListEncodings(ctx context.Context, path string) ([]string, error) {
+	c, err := executable(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.listEncodings(ctx)
+}
+
+// listEncodings runs `pdftotext -listencodings`, as in `ListEncodings`.
+func (c *Command) listEncodings(ctx context.Context) ([]string, error) {
+	out, stderr, err := c.tool(ctx, "-listencodings")
+	if err != nil {
+		return nil, err
+	}
+
+	// some versions print the list to standard error
+	if len(bytes.TrimSpace(out)) == 0 {
+		out = stderr
+	}
+
+	return parseEncodings(out), nil
+}
+
+// parseEncodings parses the output of `pdftotext -listencodings`, skipping the
+// header ("Available encodings are:") and anything else that isn't a name.
+func parseEncodings(out []byte) []string {
+	var encs []string
+
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasSuffix(line, ":") || strings.ContainsAny(line, " \t") {
+			continue
+		}
+
+		encs = append(encs, line)
+	}
+
+	return encs
+}
+
+// checkEncoding returns an error if any of `names` is not supported by the
+// command's executable, suggesting the closest supported one.
+func (c *Command) checkEncoding(ctx context.Context, names ...string) error {
+	encs, err := c.listEncodings(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if slices.Contains(encs, name) {
+			continue
+		}
+
+		closest, dist := "", -1
+		for _, enc := range encs {
+			if d := levenshtein(strings.ToLower(name), strings.ToLower(enc)); dist < 0 || d < dist {
+				closest, dist = enc, d
+			}
+		}
+
+		if closest == "" {
+			return fmt.Errorf("pdftotext: unknown encoding %q", name)
+		}
+
+		return fmt.Errorf("pdftotext: unknown encoding %q, did you mean %q?", name, closest)
+	}
+
+	return nil
+}
+
+// levenshtein returns the edit distance between `a` and `b`.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr := make([]int, len(rb)+1)
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+
+	return prev[len(rb)]
+}
+
+// decoders map encodings of `pdftotext` to their decoders into UTF-8. Other
+// names are looked up in the IANA registry.
+var decoders = map[string]encoding.Encoding{
+	"Latin1": charmap.ISO8859_1,
+	"ASCII7": encoding.Nop, // subset of UTF-8
+	"UTF-8":  encoding.Nop,
+	"UCS-2":  unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+}
+
+// decoder returns the decoder of encoding `name` into UTF-8.
+func decoder(name string) (encoding.Encoding, error) {
+	if name == "" {
+		name = "Latin1"
+	}
+	if enc, ok := decoders[name]; ok {
+		return enc, nil
+	}
+
+	enc, err := ianaindex.IANA.Encoding(name)
+	if err != nil || enc == nil {
+		return nil, fmt.Errorf("pdftotext: can't transcode encoding %q to UTF-8", name)
+	}
+
+	return enc, nil
+}
@@ -0,0 +1,197 @@
+package pdftotext
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` errors
+// ----------------------------------------------------------------------------
+
+// ErrEncrypted is returned when the PDF file is encrypted, and no valid
+// password was provided.
+var ErrEncrypted = errors.New("pdftotext: encrypted file, valid password required")
+
+// Errors matching the documented exit codes of `pdftotext`, e.g. with
+// `errors.Is(err, ErrOpenPDF)`. The exit code and the standard error are still
+// available from `*CommandError`.
+var (
+	ErrOpenPDF    = errors.New("pdftotext: error opening PDF file")           // exit code 1
+	ErrOpenOutput = errors.New("pdftotext: error opening output file")        // exit code 2
+	ErrPermission = errors.New("pdftotext: error related to PDF permissions") // exit code 3
+	ErrOther      = errors.New("pdftotext: other error")                      // exit code 99
+)
+
+// exitErrors maps exit codes of `pdftotext` to errors.
+var exitErrors = map[int]error{
+	1:  ErrOpenPDF,
+	2:  ErrOpenOutput,
+	3:  ErrPermission,
+	99: ErrOther,
+}
+
+// CommandError is returned when `pdftotext` exits with a non-zero exit code.
+type CommandError struct {
+	Command  string
+	ExitCode int
+	Stderr   string
+
+	err error
+}
+
+func (e *CommandError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("pdftotext: %s: %s", e.Command, e.err)
+	}
+
+	return fmt.Sprintf("pdftotext: %s: %s: %s", e.Command, e.err, e.Stderr)
+}
+
+// Unwrap returns the underlying error, usually `*exec.ExitError`.
+func (e *CommandError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether the error matches `target`, e.g. `ErrEncrypted` or
+// the error of the exit code, e.g. `ErrOpenPDF`.
+func (e *CommandError) Is(target error) bool {
+	if target == ErrEncrypted {
+		return e.encrypted()
+	}
+
+	return target != nil && exitErrors[e.ExitCode] == target
+}
+
+// encrypted reports whether `pdftotext` failed because of encryption, with no
+// password or a wrong one: both Xpdf and Poppler fail to open the file, with
+// exit code 1, and report an incorrect password. Exit code 3 is of a file
+// that opens, but doesn't permit copying text, so it's `ErrPermission` only.
+func (e *CommandError) encrypted() bool {
+	return e.ExitCode == 1 && strings.Contains(e.Stderr, "Incorrect password")
+}
+
+// processError maps the error of a finished `pdftotext` process, described by
+// `command`. If the context is done, its error is returned wrapped with the
+// command. A non-zero exit code is returned as `*CommandError`.
+func processError(ctx context.Context, command string, err error, stderr []byte) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s: %w", command, ctx.Err())
+	}
+
+	code, ok := exitCode(err)
+	if !ok {
+		return err
+	}
+
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		ee.Stderr = stderr
+	}
+
+	return &CommandError{
+		Command:  command,
+		ExitCode: code,
+		Stderr:   string(bytes.TrimSpace(stderr)),
+		err:      err,
+	}
+}
+
+// warnings returns diagnostics of a successful `pdftotext` run, one per
+// non-empty line of its standard error, e.g. "Syntax Warning: ...".
+func warnings(stderr []byte) []string {
+	var warns []string
+	for _, line := range strings.Split(string(stderr), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			warns = append(warns, line)
+		}
+	}
+
+	return warns
+}
+
+// ErrFontWarnings is returned with `WithStrictFonts` when `pdftotext` warns
+// about fonts, e.g. a missing non-embedded font, as the extracted characters
+// may then be wrong.
+var ErrFontWarnings = errors.New("pdftotext: font warnings, extracted text may be unreliable")
+
+// fontPatterns identify warnings about fonts of both Poppler and Xpdf.
+var fontPatterns = []string{
+	"Couldn't find a font",   // no font to substitute a non-embedded one
+	"No display font",        // Xpdf: no font to substitute a non-embedded one
+	"Unknown font tag",       // font missing from page resources
+	"Couldn't create a font", // Poppler: font can't be loaded
+	"embedded font",          // embedded font file is broken
+	"font substitut",         // font substituted, e.g. "substitute" or "substitution"
+}
+
+// fontWarnings returns the warnings in `warns` about fonts.
+func fontWarnings(warns []string) []string {
+	var fonts []string
+	for _, w := range warns {
+		if slices.ContainsFunc(fontPatterns, func(p string) bool { return strings.Contains(w, p) }) {
+			fonts = append(fonts, w)
+		}
+	}
+
+	return fonts
+}
+
+// strictFonts returns `ErrFontWarnings` listing the font warnings in `warns`,
+// if any, with `WithStrictFonts`.
+func (c *Command) strictFonts(warns []string) error {
+	if !c.failOnFonts {
+		return nil
+	}
+	if fonts := fontWarnings(warns); len(fonts) > 0 {
+		return fmt.Errorf("%w: %s", ErrFontWarnings, strings.Join(fonts, "; "))
+	}
+
+	return nil
+}
+
+// warn passes warnings of a successful `pdftotext` run to the handler set
+// with `WithOnWarning`, and returns them.
+func (c *Command) warn(stderr []byte) []string {
+	warns := warnings(stderr)
+	if c.onWarning != nil {
+		for _, w := range warns {
+			c.onWarning(w)
+		}
+	}
+
+	return warns
+}
+
+// RunWithPasswordRetry executes prepared `pdftotext` command, and if it fails
+// with `ErrEncrypted`, retries once with the password produced by `fn`, passed
+// both as the user and the owner password.
+//
+// `fn` is called only for encrypted files. The error of the retry, if any, is
+// returned as is.
+func (c *Command) RunWithPasswordRetry(ctx context.Context, inpath string, fn func(ctx context.Context) (string, error)) (io.Reader, error) {
+	out, err := c.Run(ctx, inpath)
+	if !errors.Is(err, ErrEncrypted) {
+		return out, err
+	}
+
+	password, err := fn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cc := c.Clone()
+	WithUserPassword(password)(cc)
+	WithOwnerPassword(password)(cc)
+
+	return cc.Run(ctx, inpath)
+}
@@ -1,3 +1,5 @@
 module github.com/dosadczuk/go-pdftotext
 
 go 1.22
+
+require golang.org/x/text v0.21.0
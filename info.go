@@ -0,0 +1,106 @@
+package pdftotext
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` info
+// ----------------------------------------------------------------------------
+
+// Info is lightweight metadata of a PDF file.
+type Info struct {
+	// Pages is the number of pages, 0 if the file is encrypted.
+	Pages int
+
+	// Version is the PDF version from the file header, e.g. "1.7".
+	Version string
+
+	// Encrypted reports whether the file couldn't be opened without a valid
+	// password. Files encrypted with an empty user password open as usual.
+	Encrypted bool
+
+	// Width and Height are dimensions of the first page, in points. Both are
+	// 0 if not obtainable.
+	Width  float64
+	Height float64
+}
+
+var pdfHeader = regexp.MustCompile(`%PDF-(\d+\.\d+)`)
+
+// Info gathers metadata of the PDF file at `inpath`.
+//
+// The version is read from the file header. The rest comes from a single
+// `pdftotext` run, picked by the detected variant, which is cached as in
+// `DetectVariant`: a `-bbox` run with Poppler, and a `-raw` run with Xpdf,
+// where page dimensions aren't available. If the variant is unknown, the
+// `-raw` run is the fallback of a failed `-bbox` one. Anything more, e.g. the
+// document metadata, requires `pdfinfo`.
+func (c *Command) Info(ctx context.Context, inpath string) (Info, error) {
+	var info Info
+
+	f, err := os.Open(c.resolve(inpath))
+	if err != nil {
+		return info, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 1024)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return info, err
+	}
+	if m := pdfHeader.FindSubmatch(header[:n]); m != nil {
+		info.Version = string(m[1])
+	}
+
+	inpath, err = c.inpath(inpath)
+	if err != nil {
+		return info, err
+	}
+
+	v, err := c.variant(ctx)
+	if err != nil {
+		return info, err
+	}
+
+	if v != VariantXpdf {
+		out, _, err := c.withArgs("-bbox").exec(ctx, inpath, nil)
+		if err == nil {
+			pages := bboxPage.FindAllSubmatch(out, -1)
+			info.Pages = len(pages)
+			if len(pages) > 0 {
+				info.Width, _ = strconv.ParseFloat(string(pages[0][1]), 64)
+				info.Height, _ = strconv.ParseFloat(string(pages[0][2]), 64)
+			}
+
+			return info, nil
+		}
+		if errors.Is(err, ErrEncrypted) {
+			info.Encrypted = true
+			return info, nil
+		}
+		if v == VariantPoppler {
+			return info, err
+		}
+	}
+
+	out, _, err := c.withRaw().exec(ctx, inpath, nil)
+	if errors.Is(err, ErrEncrypted) {
+		info.Encrypted = true
+		return info, nil
+	}
+	if err != nil {
+		return info, err
+	}
+
+	info.Pages = bytes.Count(out, []byte(pageBreak))
+
+	return info, nil
+}
@@ -0,0 +1,95 @@
+package pdftotext
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestInfo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	bbox := `<doc><page width="612.000000" height="792.000000"></page><page width="595.000000" height="842.000000"></page></doc>`
+
+	tests := []struct {
+		name   string
+		banner string
+		bbox   bool // `-bbox` is supported
+		want   Info
+		runs   [][]string
+	}{
+		{
+			"Poppler", popplerBanner, true,
+			Info{Pages: 2, Version: "1.7", Width: 612, Height: 792},
+			[][]string{{"-table", "-bbox", path, "-"}},
+		},
+		{
+			"Xpdf", xpdfBanner, false,
+			Info{Pages: 2, Version: "1.7"},
+			[][]string{{"-table", path, "-"}},
+		},
+		{
+			"unknown", "pdftotext 1.0\n", false,
+			Info{Pages: 2, Version: "1.7"},
+			[][]string{{"-table", "-bbox", path, "-"}, {"-table", path, "-"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{banner: tt.banner, respond: func(args []string) fakeResult {
+				switch {
+				case slices.Contains(args, "-bbox") && tt.bbox:
+					return fakeResult{stdout: bbox}
+				case slices.Contains(args, "-bbox"):
+					return fakeResult{stderr: "unknown option -bbox", code: 99}
+				}
+				return fakeResult{stdout: "one\ftwo\f"}
+			}}
+			cmd := newFake(t, f, WithModeTable())
+
+			info, err := cmd.Info(context.Background(), path)
+			if err != nil {
+				t.Fatalf("Info() error = %v", err)
+			}
+			if info != tt.want {
+				t.Errorf("Info() = %+v, want %+v", info, tt.want)
+			}
+
+			got := f.conversions()
+			if !slices.EqualFunc(got, tt.runs, slices.Equal[[]string]) {
+				t.Errorf("runs = %q, want %q", got, tt.runs)
+			}
+		})
+	}
+}
+
+func TestInfoEncrypted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, banner := range []string{popplerBanner, xpdfBanner} {
+		f := &fakeRunner{banner: banner, respond: func([]string) fakeResult {
+			return fakeResult{stderr: "Command Line Error: Incorrect password\n", code: 1}
+		}}
+		cmd := newFake(t, f)
+
+		info, err := cmd.Info(context.Background(), path)
+		if err != nil {
+			t.Fatalf("Info() error = %v", err)
+		}
+		if want := (Info{Version: "1.4", Encrypted: true}); info != want {
+			t.Errorf("Info() = %+v, want %+v", info, want)
+		}
+		if got := f.conversions(); len(got) != 1 {
+			t.Errorf("runs = %q, want 1", got)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package pdftotext
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` input
+// ----------------------------------------------------------------------------
+
+// ErrStdinUnsupported is returned when `pdftotext` can't read the PDF file from
+// standard input, e.g. Xpdf, which treats "-" as a file name.
+var ErrStdinUnsupported = errors.New("pdftotext: reading from standard input is not supported")
+
+// RunReader executes prepared `pdftotext` command for the PDF file read from
+// `r`, passed through standard input.
+//
+// It works only if `pdftotext` can read from standard input, which is true
+// for Poppler. Otherwise `ErrStdinUnsupported` is returned. The reader is not
+// closed; see `RunStdin` to hand it over.
+func (c *Command) RunReader(ctx context.Context, r io.Reader) (io.Reader, error) {
+	return c.runStdin(ctx, r)
+}
+
+// StdinOwnership tells who closes the reader passed to `RunStdin`.
+type StdinOwnership int
+
+const (
+	// CallerOwnsStdin leaves the reader open; the caller closes it, if
+	// needed, once `RunStdin` returns.
+	CallerOwnsStdin StdinOwnership = iota
+
+	// CommandOwnsStdin closes the reader, if it's an `io.Closer`, once the
+	// process finishes, whether it succeeds or not.
+	CommandOwnsStdin
+)
+
+// RunStdin executes prepared `pdftotext` command for the PDF file read from
+// `r`, attached as standard input of the process, with "-" as the input file.
+//
+// An `*os.File`, e.g. an open file or a pipe, is passed to the process as is,
+// so `pdftotext` reads it directly, without copying it in a goroutine, and
+// the backpressure is up to the file. Any other reader is copied as it's read.
+// Whether `r` is closed is set with `own`.
+//
+// As `RunReader`, it works only if `pdftotext` can read from standard input.
+func (c *Command) RunStdin(ctx context.Context, r io.Reader, own StdinOwnership) (io.Reader, error) {
+	if rc, ok := r.(io.Closer); ok && own == CommandOwnsStdin {
+		defer rc.Close()
+	}
+
+	return c.runStdin(ctx, r)
+}
+
+// runStdin executes prepared `pdftotext` command for the PDF file read from
+// standard input.
+func (c *Command) runStdin(ctx context.Context, stdin io.Reader) (io.Reader, error) {
+	out, err := c.run(ctx, "-", stdin)
+
+	var ce *CommandError
+	if errors.As(err, &ce) && strings.Contains(ce.Stderr, "Couldn't open file '-'") {
+		return nil, fmt.Errorf("%w: %w", ErrStdinUnsupported, err)
+	}
+
+	return out, err
+}
+
+// RunFromBytesWithHash executes prepared `pdftotext` command for the PDF file
+// in `data`, passed through standard input. Along with the output it returns
+// the hex-encoded hash of `data`, computed while the input is streamed.
+//
+// The hash is SHA-256, unless set with `WithInputHash`. With
+// `ErrTooManyPages`, the output of the allowed pages and the hash are returned
+// along with the error.
+func (c *Command) RunFromBytesWithHash(ctx context.Context, data []byte) (io.Reader, string, error) {
+	h := c.hash()
+	in := bytes.NewReader(data)
+
+	out, err := c.runStdin(ctx, io.TeeReader(in, h))
+	if failed(err) {
+		return nil, "", err
+	}
+
+	// hash what `pdftotext` didn't read, if it stopped early
+	if _, err := io.Copy(h, in); err != nil {
+		return nil, "", err
+	}
+
+	return out, hex.EncodeToString(h.Sum(nil)), err
+}
+
+// RunFS executes prepared `pdftotext` command for the PDF file `name` opened
+// from `fsys`, e.g. an `embed.FS`, passed through standard input as with
+// `RunReader`.
+//
+// Errors of opening the file and of the conversion are wrapped with distinct
+// messages, while the underlying errors are still matched with `errors.Is`.
+func (c *Command) RunFS(ctx context.Context, fsys fs.FS, name string) (io.Reader, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("pdftotext: open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	out, err := c.runStdin(ctx, f)
+	if failed(err) {
+		return nil, fmt.Errorf("pdftotext: convert %s: %w", name, err)
+	}
+
+	return out, err
+}
+
+// InputProvider provides a PDF file from an arbitrary source.
+type InputProvider interface {
+	// Name returns a logical name of the file, e.g. for error messages.
+	Name() string
+
+	// Open opens the file for reading.
+	Open(ctx context.Context) (io.ReadCloser, error)
+}
+
+// RunProvider executes prepared `pdftotext` command for the PDF file provided
+// by `p`, passed through standard input.
+func (c *Command) RunProvider(ctx context.Context, p InputProvider) (io.Reader, error) {
+	in, err := p.Open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("pdftotext: open %s: %w", p.Name(), err)
+	}
+	defer in.Close()
+
+	return c.runStdin(ctx, in)
+}
+
+// FileInput returns an InputProvider for the file at `path`.
+func FileInput(path string) InputProvider {
+	return fileInput(path)
+}
+
+type fileInput string
+
+func (f fileInput) Name() string {
+	return string(f)
+}
+
+func (f fileInput) Open(context.Context) (io.ReadCloser, error) {
+	return os.Open(string(f))
+}
+
+// ReaderInput returns an InputProvider for the file read from `r`, named
+// `name`. The reader can be consumed only once.
+func ReaderInput(name string, r io.Reader) InputProvider {
+	return readerInput{name: name, r: r}
+}
+
+type readerInput struct {
+	name string
+	r    io.Reader
+}
+
+func (r readerInput) Name() string {
+	return r.name
+}
+
+func (r readerInput) Open(context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(r.r), nil
+}
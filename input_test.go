@@ -0,0 +1,214 @@
+package pdftotext
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// echoRunner returns a Runner writing standard input to standard output, and
+// storing the reader attached as standard input in `stdin`.
+func echoRunner(stdin *io.Reader) Runner {
+	return RunnerFunc(func(ctx context.Context, cmd *exec.Cmd) error {
+		if slices.Equal(cmd.Args[1:], []string{"-v"}) {
+			_, err := io.WriteString(cmd.Stderr, popplerBanner)
+			return err
+		}
+		if stdin != nil {
+			*stdin = cmd.Stdin
+		}
+
+		_, err := io.Copy(cmd.Stdout, cmd.Stdin)
+		return err
+	})
+}
+
+// closeTracker is a reader recording whether it was closed.
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+// mapInput is an InputProvider of the file `name` of in-memory `files`.
+type mapInput struct {
+	files  map[string]string
+	name   string
+	opened *closeTracker
+}
+
+func (m *mapInput) Name() string {
+	return m.name
+}
+
+func (m *mapInput) Open(context.Context) (io.ReadCloser, error) {
+	data, ok := m.files[m.name]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+
+	m.opened = &closeTracker{Reader: strings.NewReader(data)}
+	return m.opened, nil
+}
+
+func TestRunFromBytesWithHash(t *testing.T) {
+	data := []byte("%PDF-1.7 fake")
+	sum := sha256.Sum256(data)
+	want := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name string
+		opts []option
+		out  string
+		err  error
+	}{
+		{"all pages", nil, "1\n\f2\n\f3\n\f", nil},
+		{"too many pages", []option{WithMaxPagesFast(2)}, "1\n\f2\n\f", ErrTooManyPages},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newFake(t, pagesRunner(3), tt.opts...)
+
+			out, hash, err := cmd.RunFromBytesWithHash(context.Background(), data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("RunFromBytesWithHash() error = %v, want %v", err, tt.err)
+			}
+			if hash != want {
+				t.Errorf("RunFromBytesWithHash() hash = %s, want %s", hash, want)
+			}
+			if b, _ := io.ReadAll(out); string(b) != tt.out {
+				t.Errorf("RunFromBytesWithHash() = %q, want %q", b, tt.out)
+			}
+		})
+	}
+}
+
+func TestRunProvider(t *testing.T) {
+	files := map[string]string{"a.pdf": "text of a\f"}
+
+	cmd, err := NewCommand(WithRunner(echoRunner(nil)))
+	if err != nil {
+		t.Fatalf("NewCommand() error = %v", err)
+	}
+
+	p := &mapInput{files: files, name: "a.pdf"}
+	out, err := cmd.RunProvider(context.Background(), p)
+	if err != nil {
+		t.Fatalf("RunProvider() error = %v", err)
+	}
+	if b, _ := io.ReadAll(out); string(b) != "text of a\f" {
+		t.Errorf("RunProvider() = %q, want %q", b, "text of a\f")
+	}
+	if !p.opened.closed {
+		t.Error("provided reader not closed")
+	}
+
+	_, err = cmd.RunProvider(context.Background(), &mapInput{files: files, name: "b.pdf"})
+	if !errors.Is(err, fs.ErrNotExist) || !strings.Contains(err.Error(), "open b.pdf") {
+		t.Errorf("RunProvider() error = %v, want open b.pdf: fs.ErrNotExist", err)
+	}
+}
+
+func TestRunProviderFailure(t *testing.T) {
+	cmd := newFake(t, &fakeRunner{respond: func([]string) fakeResult {
+		return fakeResult{stderr: "Syntax Error: broken", code: 1}
+	}})
+
+	p := &mapInput{files: map[string]string{"a.pdf": "broken"}, name: "a.pdf"}
+	if _, err := cmd.RunProvider(context.Background(), p); !errors.Is(err, ErrOpenPDF) {
+		t.Fatalf("RunProvider() error = %v, want ErrOpenPDF", err)
+	}
+
+	// closed whether the conversion succeeds or not
+	if !p.opened.closed {
+		t.Error("provided reader not closed")
+	}
+}
+
+func TestRunStdin(t *testing.T) {
+	tests := []struct {
+		name   string
+		own    StdinOwnership
+		closed bool
+	}{
+		{"caller owns", CallerOwnsStdin, false},
+		{"command owns", CommandOwnsStdin, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdin io.Reader
+			cmd, err := NewCommand(WithRunner(echoRunner(&stdin)))
+			if err != nil {
+				t.Fatalf("NewCommand() error = %v", err)
+			}
+
+			r := &closeTracker{Reader: strings.NewReader("text\f")}
+			out, err := cmd.RunStdin(context.Background(), r, tt.own)
+			if err != nil {
+				t.Fatalf("RunStdin() error = %v", err)
+			}
+			if b, _ := io.ReadAll(out); string(b) != "text\f" {
+				t.Errorf("RunStdin() = %q, want %q", b, "text\f")
+			}
+			if stdin != io.Reader(r) {
+				t.Errorf("stdin = %T, want the reader", stdin)
+			}
+			if r.closed != tt.closed {
+				t.Errorf("closed = %t, want %t", r.closed, tt.closed)
+			}
+		})
+	}
+}
+
+func TestRunStdinFile(t *testing.T) {
+	for _, own := range []StdinOwnership{CallerOwnsStdin, CommandOwnsStdin} {
+		path := filepath.Join(t.TempDir(), "in.pdf")
+		if err := os.WriteFile(path, []byte("text\f"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var stdin io.Reader
+		cmd, err := NewCommand(WithRunner(echoRunner(&stdin)))
+		if err != nil {
+			t.Fatalf("NewCommand() error = %v", err)
+		}
+
+		out, err := cmd.RunStdin(context.Background(), f, own)
+		if err != nil {
+			t.Fatalf("RunStdin() error = %v", err)
+		}
+		if b, _ := io.ReadAll(out); string(b) != "text\f" {
+			t.Errorf("RunStdin() = %q, want %q", b, "text\f")
+		}
+
+		// the file is attached as is, for the process to read it directly
+		if stdin != io.Reader(f) {
+			t.Errorf("stdin = %T, want the *os.File", stdin)
+		}
+
+		err = f.Close()
+		if closed := errors.Is(err, os.ErrClosed); closed != (own == CommandOwnsStdin) {
+			t.Errorf("ownership %d: file closed = %t", own, closed)
+		}
+	}
+}
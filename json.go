@@ -0,0 +1,172 @@
+package pdftotext
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` serialization
+// ----------------------------------------------------------------------------
+
+// Config is a serializable configuration of a command, e.g. to store it along
+// with the output and to rebuild the command later with `NewCommandFromConfig`.
+//
+// Only what's passed to `pdftotext` is captured, and Go-side options, e.g.
+// transforms, hooks or timeouts, aren't. Passwords are never captured, nor
+// are config-files read with `WithConfigReader`.
+type Config struct {
+	Path    string   `json:"path"`
+	Args    []string `json:"args,omitempty"`
+	WorkDir string   `json:"workDir,omitempty"`
+
+	// ConfigRelative reports whether `WithConfigRelativePaths` is enabled.
+	ConfigRelative bool `json:"configRelative,omitempty"`
+
+	// Passwords reports whether any password was set, and omitted. The
+	// rebuilt command needs them again, e.g. with `WithUserPassword`.
+	Passwords bool `json:"passwords,omitempty"`
+}
+
+// Config returns the serializable configuration of the command.
+func (c *Command) Config() Config {
+	cfg := Config{
+		Path:           c.path,
+		WorkDir:        c.workDir,
+		ConfigRelative: c.configRelative,
+		Passwords:      len(c.secrets) > 0,
+	}
+
+	for i := 0; i < len(c.args); i++ {
+		// drop the flag along with its value
+		if i+1 < len(c.args) && c.omitted(i+1) {
+			i++
+			continue
+		}
+
+		cfg.Args = append(cfg.Args, c.args[i])
+	}
+
+	return cfg
+}
+
+// omitted reports whether the arg at index `i` is left out of `Config`.
+func (c *Command) omitted(i int) bool {
+	return slices.Contains(c.secrets, i) || slices.ContainsFunc(c.lazy, func(arg lazyArg) bool {
+		return arg.index == i
+	})
+}
+
+// MarshalJSON encodes the command as its `Config`.
+func (c *Command) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Config())
+}
+
+// UnmarshalJSON decodes the command from its `Config`, rebuilt as with
+// `NewCommandFromConfig`.
+func (c *Command) UnmarshalJSON(data []byte) error {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+
+	cmd, err := NewCommandFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	*c = *cmd
+
+	return nil
+}
+
+// NewCommandFromConfig creates new `pdftotext` command from `cfg`, applying
+// `opts` on top of it, e.g. passwords or transforms. Flags in `cfg.Args` are
+// validated as if set with their options.
+//
+// If `cfg.Path` is empty, the executable is searched for as in `NewCommand`.
+func NewCommandFromConfig(cfg Config, opts ...option) (*Command, error) {
+	base := []option{
+		WithCustomPath(cfg.Path),
+		withConfigArgs(cfg.Args),
+		WithConfigRelativePaths(cfg.ConfigRelative),
+	}
+	if cfg.WorkDir != "" {
+		base = append(base, WithWorkingDir(cfg.WorkDir))
+	}
+
+	return NewCommand(append(base, opts...)...)
+}
+
+// valueOptions map single-value flags to the options setting them, the rest
+// are set as is.
+var valueOptions = map[string]func(value string) (option, error){
+	"-f":           uintOption(WithPageFrom),
+	"-l":           uintOption(WithPageTo),
+	"-fixed":       uintOption(WithCharFixedWidth),
+	"-linespacing": uintOption(WithLineFixedSpacing),
+	"-enc":         func(v string) (option, error) { return WithEncoding(v), nil },
+	"-eol":         func(v string) (option, error) { return WithEndOfLine(EOL(v)), nil },
+	"-cfg":         func(v string) (option, error) { return WithCustomConfig(v), nil },
+	"-opw":         func(v string) (option, error) { return WithOwnerPassword(v), nil },
+	"-upw":         func(v string) (option, error) { return WithUserPassword(v), nil },
+}
+
+// uintOption adapts an option taking a number to a flag value.
+func uintOption(fn func(n uint64) option) func(value string) (option, error) {
+	return func(value string) (option, error) {
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		return fn(n), nil
+	}
+}
+
+// valueFlags are the other flags taking a value.
+var valueFlags = []string{"-x", "-y", "-W", "-H", "-marginl", "-marginr", "-margint", "-marginb"}
+
+// withConfigArgs applies `args` of a `Config`, through the options of known
+// flags where possible.
+func withConfigArgs(args []string) option {
+	return func(c *Command) {
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+
+			// only flags are recognized, e.g. not a value of an unknown flag
+			if !strings.HasPrefix(arg, "-") {
+				c.args = append(c.args, arg)
+				continue
+			}
+
+			if mode := Mode(arg[1:]); slices.ContainsFunc(modes, func(m ModeInfo) bool { return m.Mode == mode }) {
+				WithMode(mode)(c)
+				continue
+			}
+
+			if i+1 == len(args) {
+				c.args = append(c.args, arg)
+				continue
+			}
+
+			if fn, ok := valueOptions[arg]; ok {
+				opt, err := fn(args[i+1])
+				if err != nil {
+					c.errs = append(c.errs, fmt.Errorf("pdftotext: invalid value of %s: %w", arg, err))
+				} else {
+					opt(c)
+				}
+				i++
+			} else if slices.Contains(valueFlags, arg) {
+				c.flag(arg, args[i+1])
+				i++
+			} else {
+				c.args = append(c.args, arg)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package pdftotext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []option
+	}{
+		{"empty", nil},
+		{"flags", []option{WithPageRange(2, 3), WithEncoding("UTF-8"), WithMargin(1, 2, 3, 4), WithEndOfLine(EOLUnix)}},
+		{"mode", []option{WithModeLayout(), WithCharFixedWidth(5)}},
+		{"unknown flag with a mode-like value", []option{WithArgs("-foo", "xraw")}},
+		{"unknown flag with a flag-like value", []option{WithArgs("-foo", "-f")}},
+		{"unknown flags", []option{WithModeTable(), WithArgs("-foo", "-bar")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{}
+			cmd := newFake(t, f, tt.opts...)
+
+			cfg := cmd.Config()
+			got, err := NewCommandFromConfig(cfg, WithRunner(f))
+			if err != nil {
+				t.Fatalf("NewCommandFromConfig() error = %v", err)
+			}
+
+			if want := cmd.Args("in.pdf"); !slices.Equal(got.Args("in.pdf"), want) {
+				t.Errorf("Args() = %q, want %q", got.Args("in.pdf"), want)
+			}
+			if !slices.Equal(got.modes, cmd.modes) {
+				t.Errorf("modes = %q, want %q", got.modes, cmd.modes)
+			}
+		})
+	}
+}
+
+func TestConfigPasswords(t *testing.T) {
+	cmd := newFake(t, &fakeRunner{}, WithUserPassword("s3cret"), WithPageFrom(2))
+
+	cfg := cmd.Config()
+	if want := []string{"-f", "2"}; !slices.Equal(cfg.Args, want) || !cfg.Passwords {
+		t.Errorf("Config() = %+v, want args %q and passwords", cfg, want)
+	}
+}
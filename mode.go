@@ -0,0 +1,93 @@
+package pdftotext
+
+import (
+	"context"
+	"fmt"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` modes
+// ----------------------------------------------------------------------------
+
+// Mode is a text layout mode of `pdftotext`.
+type Mode string
+
+const (
+	ModeLayout      Mode = "layout"
+	ModeSimple      Mode = "simple"
+	ModeSimple2     Mode = "simple2"
+	ModeTable       Mode = "table"
+	ModeLinePrinter Mode = "lineprinter"
+	ModeRaw         Mode = "raw"
+)
+
+// ModeInfo describes a text layout mode.
+type ModeInfo struct {
+	Mode        Mode
+	Description string
+	Option      option
+
+	// Variant is the only variant supporting the mode, or `VariantUnknown`
+	// if both do.
+	Variant Variant
+}
+
+var modes = []ModeInfo{
+	{ModeLayout, "Maintain the original physical layout of the text.", WithModeLayout(), VariantUnknown},
+	{ModeSimple, "Physical layout optimized for simple one-column pages.", WithModeSimple(), VariantUnknown},
+	{ModeSimple2, "Simple layout that handles slightly rotated text better.", WithModeSimple2(), VariantXpdf},
+	{ModeTable, "Physical layout optimized for tabular data.", WithModeTable(), VariantUnknown},
+	{ModeLinePrinter, "Strict fixed-character-pitch and -height layout.", WithModeLinePrinter(), VariantXpdf},
+	{ModeRaw, "Keep the text in content stream order.", WithModeRaw(), VariantUnknown},
+}
+
+// Modes returns all text layout modes, e.g. to offer them in a UI.
+//
+// Modes are not checked against the installed `pdftotext`, so some of them
+// may not be supported by it. Use `Command.Modes` to get only the supported
+// ones.
+func Modes() []ModeInfo {
+	return append([]ModeInfo(nil), modes...)
+}
+
+// ModesFor returns text layout modes supported by variant `v` of `pdftotext`,
+// or all of them for `VariantUnknown`.
+func ModesFor(v Variant) []ModeInfo {
+	var supported []ModeInfo
+	for _, m := range modes {
+		if v == VariantUnknown || m.Variant == VariantUnknown || m.Variant == v {
+			supported = append(supported, m)
+		}
+	}
+
+	return supported
+}
+
+// Modes returns text layout modes supported by the command's executable, as
+// in `ModesFor`, e.g. to offer them in a UI. It runs `pdftotext -v` to detect
+// the variant.
+func (c *Command) Modes(ctx context.Context) ([]ModeInfo, error) {
+	v, err := c.variant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ModesFor(v), nil
+}
+
+// Sets the text layout mode. It's the preferred way over the individual mode
+// options, e.g. `WithModeLayout`, as it takes a single mode.
+//
+// An unknown mode makes `NewCommand` fail.
+func WithMode(mode Mode) option {
+	return func(c *Command) {
+		for _, m := range modes {
+			if m.Mode == mode {
+				m.Option(c)
+				return
+			}
+		}
+
+		c.errs = append(c.errs, fmt.Errorf("pdftotext: unknown mode %q", mode))
+	}
+}
@@ -0,0 +1,72 @@
+package pdftotext
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// modeNames returns the modes of `infos`.
+func modeNames(infos []ModeInfo) []Mode {
+	names := make([]Mode, len(infos))
+	for i, m := range infos {
+		names[i] = m.Mode
+	}
+
+	return names
+}
+
+func TestModesFor(t *testing.T) {
+	all := []Mode{ModeLayout, ModeSimple, ModeSimple2, ModeTable, ModeLinePrinter, ModeRaw}
+
+	tests := []struct {
+		variant Variant
+		want    []Mode
+	}{
+		{VariantPoppler, []Mode{ModeLayout, ModeSimple, ModeTable, ModeRaw}},
+		{VariantXpdf, all},
+		{VariantUnknown, all},
+	}
+
+	for _, tt := range tests {
+		if got := modeNames(ModesFor(tt.variant)); !slices.Equal(got, tt.want) {
+			t.Errorf("ModesFor(%v) = %q, want %q", tt.variant, got, tt.want)
+		}
+	}
+
+	if got := modeNames(Modes()); !slices.Equal(got, all) {
+		t.Errorf("Modes() = %q, want %q", got, all)
+	}
+}
+
+func TestCommandModes(t *testing.T) {
+	for _, banner := range []string{popplerBanner, xpdfBanner} {
+		cmd := newFake(t, &fakeRunner{banner: banner})
+
+		got, err := cmd.Modes(context.Background())
+		if err != nil {
+			t.Fatalf("Modes() error = %v", err)
+		}
+
+		// every offered mode is accepted, and every other one rejected
+		for _, m := range Modes() {
+			_, err := NewCommand(WithRunner(&fakeRunner{banner: banner}), WithMode(m.Mode))
+			offered := slices.Contains(modeNames(got), m.Mode)
+			if offered != (err == nil) {
+				t.Errorf("%s: mode %s offered = %t, NewCommand() error = %v", firstLine([]byte(banner)), m.Mode, offered, err)
+			}
+		}
+	}
+}
+
+func TestWithMode(t *testing.T) {
+	for _, m := range ModesFor(VariantPoppler) {
+		if got, want := argv(t, WithMode(m.Mode)), argv(t, m.Option); !slices.Equal(got, want) || got[0] != "-"+string(m.Mode) {
+			t.Errorf("WithMode(%s) args = %q, want %q", m.Mode, got, want)
+		}
+	}
+
+	if _, err := NewCommand(WithRunner(&fakeRunner{}), WithMode("fancy")); err == nil {
+		t.Error("WithMode(fancy) error = nil, want error")
+	}
+}
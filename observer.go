@@ -0,0 +1,47 @@
+package pdftotext
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` observer
+// ----------------------------------------------------------------------------
+
+// ExecInfo describes a finished conversion by `pdftotext`.
+type ExecInfo struct {
+	Args     []string // passwords are redacted
+	Start    time.Time
+	End      time.Time
+	ExitCode int   // -1 if the process didn't exit
+	Bytes    int64 // bytes written to standard output
+	Err      error
+}
+
+// observe calls the observer with `info`. A panicking observer doesn't affect
+// the conversion; the panic is ignored.
+func (c *Command) observe(info ExecInfo) {
+	defer func() { _ = recover() }()
+
+	c.observer(info)
+}
+
+// log logs the recorded execution: at debug level if it succeeded, and at
+// error level if it failed.
+func (c *Command) log(ctx context.Context, rec Recording) {
+	attrs := []slog.Attr{
+		slog.Any("args", rec.Args),
+		slog.Duration("duration", rec.Duration),
+		slog.Int("exit_code", rec.ExitCode),
+	}
+
+	if rec.Err != nil {
+		attrs = append(attrs, slog.Any("error", rec.Err))
+		c.logger.LogAttrs(ctx, slog.LevelError, "pdftotext failed", attrs...)
+		return
+	}
+
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "pdftotext finished", attrs...)
+}
@@ -0,0 +1,62 @@
+package pdftotext
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestWithObserver(t *testing.T) {
+	f := &fakeRunner{respond: func(args []string) fakeResult {
+		if slices.Equal(args, []string{"-listencodings"}) {
+			return fakeResult{stdout: "Available encodings are:\nUTF-8\n"}
+		}
+		return fakeResult{stdout: "text\f"}
+	}}
+
+	var infos []ExecInfo
+	cmd := newFake(t, f, WithObserver(func(info ExecInfo) {
+		infos = append(infos, info)
+	}))
+
+	// runs querying the executable aren't observed
+	if err := cmd.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if err := cmd.checkEncoding(context.Background(), "UTF-8"); err != nil {
+		t.Fatalf("checkEncoding() error = %v", err)
+	}
+	if _, err := cmd.RunString(context.Background(), "in.pdf"); err != nil {
+		t.Fatalf("RunString() error = %v", err)
+	}
+
+	if len(infos) != 1 {
+		t.Fatalf("observed %d runs, want 1", len(infos))
+	}
+	if want := []string{"pdftotext", "in.pdf", "-"}; !slices.Equal(infos[0].Args, want) {
+		t.Errorf("ExecInfo.Args = %q, want %q", infos[0].Args, want)
+	}
+	if infos[0].ExitCode != 0 || infos[0].Bytes != 5 || infos[0].Err != nil {
+		t.Errorf("ExecInfo = %+v, want exit code 0 and 5 bytes", infos[0])
+	}
+}
+
+func TestWithObserverPanic(t *testing.T) {
+	calls := 0
+	cmd := newFake(t, &fakeRunner{respond: output("text\f")}, WithObserver(func(ExecInfo) {
+		calls++
+		panic("observer failed")
+	}))
+
+	// the panic doesn't affect the conversion
+	out, err := cmd.RunString(context.Background(), "in.pdf")
+	if err != nil {
+		t.Fatalf("RunString() error = %v", err)
+	}
+	if out != "text\f" {
+		t.Errorf("RunString() = %q, want %q", out, "text\f")
+	}
+	if calls != 1 {
+		t.Errorf("observer called %d times, want 1", calls)
+	}
+}
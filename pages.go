@@ -0,0 +1,503 @@
+package pdftotext
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` pages
+// ----------------------------------------------------------------------------
+
+// pageBreak is the character `pdftotext` inserts at the end of each page.
+const pageBreak = "\f"
+
+// Page is the text of a single page.
+type Page struct {
+	Number int // 1-based page number within the document
+	Text   string
+}
+
+// PageGroups executes prepared `pdftotext` command and splits the output into
+// chunks of `size` pages each. Pages within a chunk are concatenated.
+//
+// The last chunk may contain fewer than `size` pages.
+func (c *Command) PageGroups(ctx context.Context, inpath string, size int) ([]string, error) {
+	if size < 1 {
+		return nil, errors.New("pdftotext: page group size must be at least 1")
+	}
+
+	pages, err := c.pages(ctx, inpath)
+	if failed(err) {
+		return nil, err
+	}
+
+	groups := make([]string, 0, (len(pages)+size-1)/size)
+	for i := 0; i < len(pages); i += size {
+		end := min(i+size, len(pages))
+		groups = append(groups, strings.Join(pages[i:end], ""))
+	}
+
+	return groups, err
+}
+
+// splitPages splits `pdftotext` output into pages on the page break. The page
+// break itself is stripped and the trailing one does not produce a page.
+func splitPages(txt string) []string {
+	if txt == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(txt, pageBreak), pageBreak)
+}
+
+// RunEachPage executes prepared `pdftotext` command and passes the output to
+// `fn` page by page, as soon as each page is produced, so only a single page
+// is held in memory. Pages are numbered as in `RunPaged`.
+//
+// If `fn` returns an error, the command is killed and the error is returned.
+// Splitting requires page breaks, so it fails with `WithNoPageBreak`.
+func (c *Command) RunEachPage(ctx context.Context, inpath string, fn func(page Page) error) error {
+	if c.noPageBreak {
+		return errNoPageBreak
+	}
+
+	return c.eachPage(ctx, inpath, fn)
+}
+
+// SearchPages executes prepared `pdftotext` command and returns numbers of the
+// pages whose text matches `re`, numbered as in `RunPaged`. Pages are searched
+// as they are produced, and the command is killed as soon as `ctx` is done.
+//
+// Splitting requires page breaks, so it fails with `WithNoPageBreak`.
+func (c *Command) SearchPages(ctx context.Context, inpath string, re *regexp.Regexp) ([]int, error) {
+	var nums []int
+
+	err := c.RunEachPage(ctx, inpath, func(page Page) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if re.MatchString(page.Text) {
+			nums = append(nums, page.Number)
+		}
+
+		return nil
+	})
+	if failed(err) {
+		return nil, err
+	}
+
+	return nums, err
+}
+
+// RunParity executes prepared `pdftotext` command and returns only the odd or
+// the even pages, e.g. the fronts or the backs of a duplex scan, each followed
+// by a page break. Pages are numbered as in `RunPaged`, so the parity is of
+// page numbers in the document, also with `WithPageRange`.
+//
+// Splitting requires page breaks, so it fails with `WithNoPageBreak`.
+func (c *Command) RunParity(ctx context.Context, inpath string, odd bool) (string, error) {
+	var sb strings.Builder
+
+	err := c.RunEachPage(ctx, inpath, func(page Page) error {
+		if (page.Number%2 == 1) == odd {
+			sb.WriteString(page.Text)
+			sb.WriteString(pageBreak)
+		}
+
+		return nil
+	})
+	if failed(err) {
+		return "", err
+	}
+
+	return sb.String(), err
+}
+
+// eachPage executes prepared `pdftotext` command and passes the output to `fn`
+// page by page as the pages are produced, numbered as in `RunPaged`.
+func (c *Command) eachPage(ctx context.Context, inpath string, fn func(page Page) error) error {
+	var (
+		page    []byte
+		num     int
+		skipped int
+	)
+
+	// leading pages are skipped before the first page is passed on
+	next := func() error {
+		num++
+		return fn(Page{Number: c.firstPage() + skipped + num - 1, Text: string(page)})
+	}
+
+	err := c.countSkipped(&skipped).RunFunc(ctx, inpath, func(chunk []byte) error {
+		for {
+			i := bytes.IndexByte(chunk, pageBreak[0])
+			if i < 0 {
+				page = append(page, chunk...)
+				return nil
+			}
+
+			page = append(page, chunk[:i]...)
+			if err := next(); err != nil {
+				return err
+			}
+
+			page, chunk = page[:0], chunk[i+1:]
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// output without the trailing page break, e.g. with `WithNoPageBreak`
+	if len(page) > 0 {
+		return next()
+	}
+
+	return nil
+}
+
+// PagesWithEncodings executes prepared `pdftotext` command using a different
+// encoding for some pages. The `encodings` map page numbers, as in the
+// document, to encoding names. Unlisted pages use `fallback`.
+//
+// The encodings are validated up front with `pdftotext -listencodings`. The
+// document is converted once with `fallback`, then each listed page is
+// converted again on its own, in page order, so every listed page costs an
+// extra process.
+func (c *Command) PagesWithEncodings(ctx context.Context, inpath string, encodings map[uint64]string, fallback string) (io.Reader, error) {
+	if fallback == "" {
+		return nil, errors.New("pdftotext: fallback encoding must not be empty")
+	}
+
+	nums := make([]uint64, 0, len(encodings))
+	for page := range encodings {
+		nums = append(nums, page)
+	}
+	slices.Sort(nums)
+
+	names := []string{fallback}
+	for _, page := range nums {
+		if encodings[page] == "" {
+			return nil, fmt.Errorf("pdftotext: encoding of page %d must not be empty", page)
+		}
+		names = append(names, encodings[page])
+	}
+
+	if err := c.checkEncoding(ctx, names...); err != nil {
+		return nil, err
+	}
+
+	cc, err := c.with(ctx, WithEncoding(fallback))
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := cc.paged(ctx, inpath)
+	if err != nil {
+		return nil, err
+	}
+
+	// pages skipped by `WithSkipLeadingPages` are out of range
+	first := uint64(c.firstPage())
+	if len(pages) > 0 {
+		first = uint64(pages[0].Number)
+	}
+	last := first + uint64(len(pages)) - 1
+
+	for _, page := range nums {
+		if page < first || page > last {
+			return nil, fmt.Errorf("pdftotext: page %d out of range %d-%d", page, first, last)
+		}
+
+		// a single page is never a leading page to skip
+		cc, err := c.withoutSkips().with(ctx, WithEncoding(encodings[page]), WithPageRange(page, page))
+		if err != nil {
+			return nil, err
+		}
+
+		txt, err := cc.pages(ctx, inpath)
+		if err != nil {
+			return nil, err
+		}
+
+		pages[page-first].Text = strings.Join(txt, "")
+	}
+
+	var sb strings.Builder
+	for _, page := range pages {
+		sb.WriteString(page.Text)
+		sb.WriteString(pageBreak)
+	}
+
+	return strings.NewReader(sb.String()), nil
+}
+
+// RunPages executes prepared `pdftotext` command and splits the output into
+// pages, with page breaks stripped.
+//
+// Splitting requires page breaks, so it fails with `WithNoPageBreak`.
+func (c *Command) RunPages(ctx context.Context, inpath string) ([]string, error) {
+	return c.pages(ctx, inpath)
+}
+
+// RunPaged executes prepared `pdftotext` command and splits the output into
+// pages, numbered as in the document, e.g. from 50 with `WithPageFrom(50)`,
+// or from 3 when 2 leading pages are skipped with `WithSkipLeadingPages`.
+//
+// Splitting requires page breaks, so it fails with `WithNoPageBreak`.
+func (c *Command) RunPaged(ctx context.Context, inpath string) ([]Page, error) {
+	return c.paged(ctx, inpath)
+}
+
+// paged executes prepared `pdftotext` command and splits the output into
+// pages, numbered as in the document.
+func (c *Command) paged(ctx context.Context, inpath string) ([]Page, error) {
+	skipped := 0
+
+	txt, err := c.countSkipped(&skipped).pages(ctx, inpath)
+	if failed(err) {
+		return nil, err
+	}
+
+	pages := make([]Page, len(txt))
+	for i, t := range txt {
+		pages[i] = Page{Number: c.firstPage() + skipped + i, Text: t}
+	}
+
+	return pages, err
+}
+
+// RunFirstPage executes prepared `pdftotext` command limited to the first page
+// of `inpath`, and returns its text with the page break stripped. The rest of
+// the document is not converted, so it is cheap even for large documents.
+func (c *Command) RunFirstPage(ctx context.Context, inpath string) (string, error) {
+	cc, err := c.with(ctx, WithFirstPageOnly())
+	if err != nil {
+		return "", err
+	}
+
+	out, err := cc.RunString(ctx, inpath)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(out, pageBreak), nil
+}
+
+// PageCount returns the number of pages of `inpath` converted by prepared
+// `pdftotext` command, e.g. all of them unless limited with `WithPageTo`.
+//
+// `pdftotext` has no way to count pages without converting them, so the text
+// is still extracted, though in the cheaper `-raw` mode and without holding it
+// in memory. It's only a little cheaper than `Run`; when the cost matters,
+// `pdfinfo` is the right tool. Counting requires page breaks, so it fails
+// with `WithNoPageBreak`.
+func (c *Command) PageCount(ctx context.Context, inpath string) (int, error) {
+	if c.noPageBreak {
+		return 0, errNoPageBreak
+	}
+
+	cc := c.withRaw()
+	cc.transforms, cc.transcode, cc.stripBOM = nil, false, false
+	cc.buffering = BlockBuffering
+
+	n := 0
+	err := cc.RunFunc(ctx, inpath, func(chunk []byte) error {
+		n += bytes.Count(chunk, []byte(pageBreak))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// withRaw returns a copy of the command in the cheap raw mode, for counting
+// pages, unless a mode is already applied, as modes are mutually exclusive.
+func (c *Command) withRaw() *Command {
+	if len(c.modes) > 0 {
+		return c.Clone()
+	}
+
+	return c.withArgs("-raw")
+}
+
+// firstPage returns the number of the first converted page.
+func (c *Command) firstPage() int {
+	if c.pageFrom > 0 {
+		return int(c.pageFrom)
+	}
+
+	return 1
+}
+
+// errNoPageBreak is returned when the output is to be split into pages, but
+// page breaks are disabled.
+var errNoPageBreak = errors.New("pdftotext: splitting into pages requires page breaks, remove WithNoPageBreak")
+
+// pages executes prepared `pdftotext` command and splits the output into pages.
+func (c *Command) pages(ctx context.Context, inpath string) ([]string, error) {
+	if c.noPageBreak {
+		return nil, errNoPageBreak
+	}
+
+	out, err := c.Run(ctx, inpath)
+	if failed(err) {
+		return nil, err
+	}
+
+	txt, rerr := io.ReadAll(out)
+	if rerr != nil {
+		return nil, rerr
+	}
+
+	// with `ErrTooManyPages`, the allowed pages are returned too
+	return splitPages(string(txt)), err
+}
+
+// IsBlankPage reports whether `page` contains only whitespace.
+func IsBlankPage(page string) bool {
+	return strings.TrimSpace(page) == ""
+}
+
+// IsSparsePage returns a function reporting whether a page has fewer than
+// `words` words, e.g. to detect cover pages.
+func IsSparsePage(words int) func(page string) bool {
+	return func(page string) bool {
+		return len(strings.Fields(page)) < words
+	}
+}
+
+// skipLeadingPages returns a transform dropping pages from the start of the
+// output for as long as `skip` reports true for them.
+func skipLeadingPages(skip func(page string) bool, report func(skipped int)) Transform {
+	return func(r io.Reader) io.Reader {
+		return &skipReader{r: bufio.NewReader(r), skip: skip, report: report}
+	}
+}
+
+// leadingSkip is a transform added by `WithSkipLeadingPages`, kept so page
+// helpers can number pages as in the document.
+type leadingSkip struct {
+	at     int // index in transforms
+	skip   func(page string) bool
+	report func(skipped int)
+}
+
+// countSkipped returns a copy of the command adding the number of leading
+// pages it skips to `n`, once they are skipped.
+func (c *Command) countSkipped(n *int) *Command {
+	if len(c.skips) == 0 {
+		return c
+	}
+
+	cc := c.Clone()
+	for _, s := range c.skips {
+		report := s.report
+		cc.transforms[s.at] = skipLeadingPages(s.skip, func(skipped int) {
+			*n += skipped
+			if report != nil {
+				report(skipped)
+			}
+		})
+	}
+
+	return cc
+}
+
+// withoutSkips returns a copy of the command not skipping leading pages.
+func (c *Command) withoutSkips() *Command {
+	if len(c.skips) == 0 {
+		return c
+	}
+
+	cc := c.Clone()
+	cc.transforms = cc.transforms[:0]
+	for i, t := range c.transforms {
+		if !slices.ContainsFunc(c.skips, func(s leadingSkip) bool { return s.at == i }) {
+			cc.transforms = append(cc.transforms, t)
+		}
+	}
+	cc.skips = nil
+
+	return cc
+}
+
+type skipReader struct {
+	r       *bufio.Reader
+	skip    func(page string) bool
+	report  func(skipped int)
+	skipped bool            // leading pages were skipped
+	head    *strings.Reader // the first page kept, not yet read
+}
+
+func (r *skipReader) Read(p []byte) (int, error) {
+	if err := r.skipPages(); err != nil {
+		return 0, err
+	}
+	if r.head.Len() > 0 {
+		return r.head.Read(p)
+	}
+
+	return r.r.Read(p)
+}
+
+// WriteTo writes the output to `w`, passing it on as is once leading pages
+// are skipped, e.g. for `io.Copy`.
+func (r *skipReader) WriteTo(w io.Writer) (int64, error) {
+	if err := r.skipPages(); err != nil {
+		return 0, err
+	}
+
+	n, err := r.head.WriteTo(w)
+	if err != nil {
+		return n, err
+	}
+
+	m, err := r.r.WriteTo(w)
+
+	return n + m, err
+}
+
+// skipPages skips leading pages, once.
+func (r *skipReader) skipPages() error {
+	if r.skipped {
+		return nil
+	}
+
+	skipped := 0
+	for {
+		page, err := r.r.ReadString(pageBreak[0])
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if page != "" && !r.skip(strings.TrimSuffix(page, pageBreak)) {
+			r.head = strings.NewReader(page)
+			break
+		}
+		if page != "" {
+			skipped++
+		}
+		if err == io.EOF {
+			r.head = strings.NewReader("")
+			break
+		}
+	}
+	r.skipped = true
+
+	if r.report != nil {
+		r.report(skipped)
+	}
+
+	return nil
+}
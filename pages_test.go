@@ -0,0 +1,298 @@
+package pdftotext
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// flagValue returns the value of `flag` in `args`, or "" if it's not set.
+func flagValue(args []string, flag string) string {
+	if i := slices.Index(args, flag); i >= 0 && i+1 < len(args) {
+		return args[i+1]
+	}
+
+	return ""
+}
+
+// encodingsRunner returns a fake converting pages 3-5 of a document, each to
+// its number and the encoding used.
+func encodingsRunner() *fakeRunner {
+	return &fakeRunner{respond: func(args []string) fakeResult {
+		if slices.Equal(args, []string{"-listencodings"}) {
+			return fakeResult{stdout: "Available encodings are:\nLatin1\nUTF-8\nASCII7\n"}
+		}
+
+		from, to := flagValue(args, "-f"), flagValue(args, "-l")
+		if from == "" {
+			from, to = "3", "5"
+		}
+
+		var out strings.Builder
+		for p := from[0]; p <= to[0]; p++ {
+			out.WriteString(string(p) + ":" + flagValue(args, "-enc") + "\f")
+		}
+
+		return fakeResult{stdout: out.String()}
+	}}
+}
+
+func TestPagesWithEncodings(t *testing.T) {
+	f := encodingsRunner()
+	cmd := newFake(t, f, WithPageFrom(3), WithPageTo(5))
+
+	out, err := cmd.PagesWithEncodings(context.Background(), "in.pdf", map[uint64]string{5: "ASCII7", 3: "UTF-8"}, "Latin1")
+	if err != nil {
+		t.Fatalf("PagesWithEncodings() error = %v", err)
+	}
+
+	b, _ := io.ReadAll(out)
+	if want := "3:UTF-8\f4:Latin1\f5:ASCII7\f"; string(b) != want {
+		t.Errorf("PagesWithEncodings() = %q, want %q", b, want)
+	}
+
+	// listed pages are converted in page order
+	var pages []string
+	for _, args := range f.conversions()[2:] {
+		pages = append(pages, flagValue(args, "-f"))
+	}
+	if want := []string{"3", "5"}; !slices.Equal(pages, want) {
+		t.Errorf("pages = %q, want %q", pages, want)
+	}
+}
+
+func TestPagesWithEncodingsInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		encodings map[uint64]string
+		fallback  string
+		want      string
+	}{
+		{"empty fallback", nil, "", "fallback encoding must not be empty"},
+		{"empty encoding", map[uint64]string{4: ""}, "Latin1", "encoding of page 4 must not be empty"},
+		{"unknown fallback", nil, "Latin2", `unknown encoding "Latin2", did you mean "Latin1"?`},
+		{"unknown encoding", map[uint64]string{4: "UTF8"}, "Latin1", `unknown encoding "UTF8", did you mean "UTF-8"?`},
+		{"page before range", map[uint64]string{2: "UTF-8"}, "Latin1", "page 2 out of range 3-5"},
+		{"page after range", map[uint64]string{6: "UTF-8"}, "Latin1", "page 6 out of range 3-5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newFake(t, encodingsRunner(), WithPageFrom(3), WithPageTo(5))
+
+			_, err := cmd.PagesWithEncodings(context.Background(), "in.pdf", tt.encodings, tt.fallback)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("PagesWithEncodings() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageCount(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []option
+		want []string
+	}{
+		{"default", nil, []string{"-raw", "in.pdf", "-"}},
+		{"layout", []option{WithModeLayout()}, []string{"-layout", "in.pdf", "-"}},
+		{"raw", []option{WithModeRaw()}, []string{"-raw", "in.pdf", "-"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{respond: output("one\ftwo\fthree\f")}
+			cmd := newFake(t, f, tt.opts...)
+
+			n, err := cmd.PageCount(context.Background(), "in.pdf")
+			if err != nil {
+				t.Fatalf("PageCount() error = %v", err)
+			}
+			if n != 3 {
+				t.Errorf("PageCount() = %d, want 3", n)
+			}
+			if got := f.conversions(); len(got) != 1 || !slices.Equal(got[0], tt.want) {
+				t.Errorf("args = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithFirstPageOnly(t *testing.T) {
+	if got, want := argv(t, WithFirstPageOnly()), []string{"-f", "1", "-l", "1", "in.pdf", "-"}; !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+
+	cmd := newFake(t, pagesRunner(3), WithFirstPageOnly())
+	pages, err := cmd.RunPages(context.Background(), "in.pdf")
+	if err != nil {
+		t.Fatalf("RunPages() error = %v", err)
+	}
+	if want := []string{"1\n"}; !slices.Equal(pages, want) {
+		t.Errorf("RunPages() = %q, want %q", pages, want)
+	}
+}
+
+func TestRunFirstPage(t *testing.T) {
+	f := pagesRunner(3)
+	cmd := newFake(t, f, WithPageRange(2, 3), WithEncoding("UTF-8"))
+
+	got, err := cmd.RunFirstPage(context.Background(), "in.pdf")
+	if err != nil {
+		t.Fatalf("RunFirstPage() error = %v", err)
+	}
+	if got != "1\n" {
+		t.Errorf("RunFirstPage() = %q, want %q", got, "1\n")
+	}
+
+	args, _ := f.last()
+	if want := []string{"-f", "1", "-l", "1", "-enc", "UTF-8", "in.pdf", "-"}; !slices.Equal(args, want) {
+		t.Errorf("args = %q, want %q", args, want)
+	}
+}
+
+func TestSkipLeadingPagesNumbering(t *testing.T) {
+	newCmd := func(t *testing.T) *Command {
+		return newFake(t, &fakeRunner{respond: output("\f \n\fthree\fblank four\f")}, WithSkipLeadingBlankPages())
+	}
+	want := []Page{{Number: 3, Text: "three"}, {Number: 4, Text: "blank four"}}
+
+	t.Run("RunPaged", func(t *testing.T) {
+		got, err := newCmd(t).RunPaged(context.Background(), "in.pdf")
+		if err != nil {
+			t.Fatalf("RunPaged() error = %v", err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("RunPaged() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("RunEachPage", func(t *testing.T) {
+		var got []Page
+		err := newCmd(t).RunEachPage(context.Background(), "in.pdf", func(page Page) error {
+			got = append(got, page)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("RunEachPage() error = %v", err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("RunEachPage() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("SearchPages", func(t *testing.T) {
+		got, err := newCmd(t).SearchPages(context.Background(), "in.pdf", regexp.MustCompile("four"))
+		if err != nil {
+			t.Fatalf("SearchPages() error = %v", err)
+		}
+		if !slices.Equal(got, []int{4}) {
+			t.Errorf("SearchPages() = %v, want [4]", got)
+		}
+	})
+
+	t.Run("RunParity", func(t *testing.T) {
+		got, err := newCmd(t).RunParity(context.Background(), "in.pdf", true)
+		if err != nil {
+			t.Fatalf("RunParity() error = %v", err)
+		}
+		if got != "three\f" {
+			t.Errorf("RunParity() = %q, want %q", got, "three\f")
+		}
+	})
+
+	t.Run("Chunks", func(t *testing.T) {
+		got, err := newCmd(t).Chunks(context.Background(), "in.pdf", 5, 0)
+		if err != nil {
+			t.Fatalf("Chunks() error = %v", err)
+		}
+		if len(got) == 0 || got[0].StartPage != 3 || got[len(got)-1].EndPage != 4 {
+			t.Errorf("Chunks() = %+v, want pages 3-4", got)
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		skipped := -1
+		cmd := newFake(t, &fakeRunner{respond: output("\f\fthree\f")}, WithSkipLeadingPages(IsBlankPage, func(n int) {
+			skipped = n
+		}))
+
+		got, err := cmd.RunPaged(context.Background(), "in.pdf")
+		if err != nil {
+			t.Fatalf("RunPaged() error = %v", err)
+		}
+		if len(got) != 1 || got[0].Number != 3 {
+			t.Errorf("RunPaged() = %+v, want page 3", got)
+		}
+		if skipped != 2 {
+			t.Errorf("report got %d, want 2", skipped)
+		}
+	})
+}
+
+func TestWithSkipLeadingPages(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		skip    func(page string) bool
+		opts    []option
+		want    string
+		skipped int
+	}{
+		{"blank", "\f \n\fone\f\ftwo\f", IsBlankPage, nil, "one\f\ftwo\f", 2},
+		{"none", "one\f\f", IsBlankPage, nil, "one\f\f", 0},
+		{"all", "\f\n\f", IsBlankPage, nil, "", 2},
+		{"sparse", "Cover\fTitle page\fsome actual text\fend\f", IsSparsePage(3), nil, "some actual text\fend\f", 2},
+		{"without trailing page break", "\fone", IsBlankPage, nil, "one", 1},
+		{"without page breaks", " \n", IsBlankPage, []option{WithNoPageBreak()}, "", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reports []int
+			opts := append([]option{WithSkipLeadingPages(tt.skip, func(n int) {
+				reports = append(reports, n)
+			})}, tt.opts...)
+			cmd := newFake(t, &fakeRunner{respond: output(tt.output)}, opts...)
+
+			got, err := cmd.RunString(context.Background(), "in.pdf")
+			if err != nil {
+				t.Fatalf("RunString() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RunString() = %q, want %q", got, tt.want)
+			}
+
+			// streamed output skips the same pages
+			var sb strings.Builder
+			if err := cmd.RunTo(context.Background(), "in.pdf", &sb); err != nil {
+				t.Fatalf("RunTo() error = %v", err)
+			}
+			if sb.String() != tt.want {
+				t.Errorf("RunTo() = %q, want %q", sb.String(), tt.want)
+			}
+
+			// reported once per run
+			if want := []int{tt.skipped, tt.skipped}; !slices.Equal(reports, want) {
+				t.Errorf("reports = %v, want %v", reports, want)
+			}
+		})
+	}
+}
+
+func TestWithSkipLeadingBlankPages(t *testing.T) {
+	cmd := newFake(t, &fakeRunner{respond: output("\f\t \r\n\fcontent\f \f")}, WithSkipLeadingBlankPages())
+
+	got, err := cmd.RunString(context.Background(), "in.pdf")
+	if err != nil {
+		t.Fatalf("RunString() error = %v", err)
+	}
+
+	// only leading blank pages are skipped
+	if want := "content\f \f"; got != want {
+		t.Errorf("RunString() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package pdftotext
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` executable
+// ----------------------------------------------------------------------------
+
+// lookPath searches for `pdftotext` executable in PATH and then in default
+// locations of the platform, and returns its path.
+func lookPath() (string, error) {
+	name, dirs := defaultLocations(runtime.GOOS)
+
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+
+	for _, dir := range dirs {
+		if path, err := exec.LookPath(filepath.Join(dir, name)); err == nil {
+			return path, nil
+		}
+	}
+
+	searched := append(filepath.SplitList(os.Getenv("PATH")), dirs...)
+
+	return "", fmt.Errorf("pdftotext: executable %q not found in %s", name, strings.Join(searched, ", "))
+}
+
+// defaultLocations returns the name of `pdftotext` executable on `goos`, and
+// directories it's commonly installed to, searched when it's not in PATH.
+func defaultLocations(goos string) (string, []string) {
+	switch goos {
+	case "windows":
+		programFiles := os.Getenv("ProgramFiles")
+		if programFiles == "" {
+			programFiles = `C:\Program Files`
+		}
+
+		return "pdftotext.exe", []string{
+			filepath.Join(programFiles, "xpdf-tools", "bin64"),
+			filepath.Join(programFiles, "poppler", "Library", "bin"),
+		}
+	case "darwin":
+		return "pdftotext", []string{"/opt/homebrew/bin", "/usr/local/bin", "/usr/bin"}
+	default:
+		return "pdftotext", []string{"/usr/local/bin", "/usr/bin"}
+	}
+}
@@ -0,0 +1,89 @@
+package pdftotext
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDefaultLocations(t *testing.T) {
+	t.Setenv("ProgramFiles", `D:\Apps`)
+
+	tests := []struct {
+		goos     string
+		wantName string
+		wantDirs []string
+	}{
+		{"windows", "pdftotext.exe", []string{
+			filepath.Join(`D:\Apps`, "xpdf-tools", "bin64"),
+			filepath.Join(`D:\Apps`, "poppler", "Library", "bin"),
+		}},
+		{"darwin", "pdftotext", []string{"/opt/homebrew/bin", "/usr/local/bin", "/usr/bin"}},
+		{"linux", "pdftotext", []string{"/usr/local/bin", "/usr/bin"}},
+		{"freebsd", "pdftotext", []string{"/usr/local/bin", "/usr/bin"}},
+	}
+
+	for _, tt := range tests {
+		name, dirs := defaultLocations(tt.goos)
+		if name != tt.wantName || !slices.Equal(dirs, tt.wantDirs) {
+			t.Errorf("defaultLocations(%q) = %q, %q, want %q, %q", tt.goos, name, dirs, tt.wantName, tt.wantDirs)
+		}
+	}
+}
+
+func TestDefaultLocationsProgramFiles(t *testing.T) {
+	t.Setenv("ProgramFiles", "")
+
+	if _, dirs := defaultLocations("windows"); !strings.HasPrefix(dirs[0], `C:\Program Files`) {
+		t.Errorf("defaultLocations() = %q, want in C:\\Program Files", dirs)
+	}
+}
+
+func TestLookPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake executable is a shell script")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pdftotext")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	got, err := lookPath()
+	if err != nil {
+		t.Fatalf("lookPath() error = %v", err)
+	}
+	if got != path {
+		t.Errorf("lookPath() = %q, want %q", got, path)
+	}
+
+	cmd, err := NewCommand()
+	if err != nil {
+		t.Fatalf("NewCommand() error = %v", err)
+	}
+	if got := cmd.Args("in.pdf")[0]; got != path {
+		t.Errorf("NewCommand() path = %q, want %q", got, path)
+	}
+}
+
+func TestLookPathNotFound(t *testing.T) {
+	_, dirs := defaultLocations(runtime.GOOS)
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, "pdftotext")); err == nil {
+			t.Skip("pdftotext is installed in a default location")
+		}
+	}
+
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+
+	_, err := lookPath()
+	if err == nil || !strings.Contains(err.Error(), dir) {
+		t.Errorf("lookPath() error = %v, want the searched %s", err, dir)
+	}
+}
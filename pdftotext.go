@@ -10,53 +10,547 @@ package pdftotext
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
 	"io"
+	"log/slog"
+	"maps"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // ----------------------------------------------------------------------------
 // -- `pdftotext`
 // ----------------------------------------------------------------------------
 
+// Command is a prepared `pdftotext` command. Once created, it's never modified,
+// so it's safe to use from multiple goroutines at once.
 type Command struct {
 	path string
 	args []string
+	dir  string // directory `pdftotext` runs in
+	env  []string
+
+	workDir string
+
+	config         int            // index of the config-file path in args
+	flags          map[string]int // indexes of values of single-value flags in args
+	secrets        []int          // indexes of args to redact
+	lazy           []lazyArg
+	configRelative bool
+
+	transforms   []Transform
+	skips        []leadingSkip // transforms skipping leading pages
+	transcode    bool          // output is decoded from `encoding` into UTF-8
+	stripBOM     bool          // leading BOM of the output encoding is removed
+	maxPages     uint64
+	timeoutAfter time.Duration
+	niceness     int
+	buffering    StreamBuffering
+	hash         func() hash.Hash
+	recorder     Recorder
+	observer     func(info ExecInfo)
+	logger       *slog.Logger
+	onWarning    func(warning string)
+	failOnFonts  bool
+	runner       Runner
+
+	pageFrom    uint64
+	pageTo      uint64
+	encoding    string
+	modes       []Mode // applied modes, for validation
+	fixed       bool
+	lineSpacing bool
+	noPageBreak bool
+
+	scannedThreshold int
+
+	errs     []error                  // errors of applied options
+	checks   []func(c *Command) error // run once the executable is found
+	requires []requirement            // variant-specific flags
 }
 
 // NewCommand creates new `pdftotext` command.
+//
+// Unless set with `WithCustomPath`, the executable is searched for in PATH
+// and then in default locations. With a custom runner set with `WithRunner`,
+// the executable isn't searched for, and the path is passed to the runner
+// as is, "pdftotext" by default.
 func NewCommand(opts ...option) (*Command, error) {
-	cmd := &Command{path: "pdftotext"}
+	cmd := &Command{hash: sha256.New, runner: execRunner{}}
 	for _, opt := range opts {
 		opt(cmd)
 	}
 
+	if err := cmd.validate(); err != nil {
+		return nil, err
+	}
+
 	var err error
 
-	// assert that executable exists and get absolute path
-	cmd.path, err = exec.LookPath(cmd.path)
+	// assert that executable exists and get absolute path, unless it's run by
+	// a custom runner, e.g. a fake one in tests, which may not need it
+	if _, ok := cmd.runner.(execRunner); ok {
+		cmd.path, err = resolvePath(cmd.path)
+	} else if cmd.path == "" {
+		cmd.path = "pdftotext"
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	for _, req := range cmd.requires {
+		if err := cmd.require(context.Background(), req); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, check := range cmd.checks {
+		if err := check(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cmd.chdir(); err != nil {
+		return nil, err
+	}
+
 	return cmd, nil
 }
 
+// chdir sets the directory `pdftotext` runs in, from the working directory
+// and the config-file with config-relative paths.
+func (c *Command) chdir() error {
+	c.dir = ""
+
+	if c.workDir != "" {
+		fi, err := os.Stat(c.workDir)
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			return fmt.Errorf("pdftotext: working directory %s is not a directory", c.workDir)
+		}
+
+		c.dir = c.workDir
+	}
+
+	// resolve config-relative resources against the config file's directory
+	if c.configRelative && c.config > 0 {
+		cfgpath, err := filepath.Abs(c.resolve(c.args[c.config]))
+		if err != nil {
+			return err
+		}
+
+		c.args[c.config] = cfgpath
+		c.dir = filepath.Dir(cfgpath)
+	}
+
+	return nil
+}
+
+// validate reports errors of applied options and invalid combinations of them,
+// which `pdftotext` would silently ignore.
+func (c *Command) validate() error {
+	errs := slices.Clone(c.errs)
+
+	modes := slices.Clone(c.modes)
+	slices.Sort(modes)
+	if modes = slices.Compact(modes); len(modes) > 1 {
+		flags := make([]string, len(modes))
+		for i, m := range modes {
+			flags[i] = "-" + string(m)
+		}
+		errs = append(errs, fmt.Errorf("pdftotext: conflicting modes %s, only one is allowed", strings.Join(flags, ", ")))
+	}
+
+	if c.pageFrom > 0 && c.pageTo > 0 && c.pageFrom > c.pageTo {
+		errs = append(errs, fmt.Errorf("pdftotext: first page %d is after last page %d", c.pageFrom, c.pageTo))
+	}
+	if c.transcode {
+		if _, err := decoder(c.encoding); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.fixed && !c.hasMode(ModeLayout, ModeTable, ModeLinePrinter) {
+		errs = append(errs, errors.New("pdftotext: -fixed requires one of -layout, -table, -lineprinter"))
+	}
+	if c.lineSpacing && !c.hasMode(ModeLinePrinter) {
+		errs = append(errs, errors.New("pdftotext: -linespacing requires -lineprinter"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// hasMode reports whether any of `modes` was applied.
+func (c *Command) hasMode(modes ...Mode) bool {
+	for _, m := range modes {
+		if slices.Contains(c.modes, m) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Run executes prepared `pdftotext` command.
+//
+// The process is killed when `ctx` is done, and the context error is returned
+// wrapped with the command. The returned reader implements `io.WriterTo`,
+// unless a transform of `WithTransforms` doesn't, so `io.Copy` writes the
+// output without an intermediate buffer.
 func (c *Command) Run(ctx context.Context, inpath string) (io.Reader, error) {
-	cmd := exec.CommandContext(ctx, c.path, append(c.args, inpath, "-")...)
+	inpath, err := c.inpath(inpath)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.run(ctx, inpath, nil)
+}
+
+// RunString executes prepared `pdftotext` command and returns the output as
+// a string.
+func (c *Command) RunString(ctx context.Context, inpath string) (string, error) {
+	out, err := c.RunBytes(ctx, inpath)
+	return string(out), err
+}
+
+// RunBytes executes prepared `pdftotext` command and returns the output as
+// bytes, exactly as produced, e.g. including the BOM of `WithByteOrderMarker`,
+// unless changed by transforms.
+func (c *Command) RunBytes(ctx context.Context, inpath string) ([]byte, error) {
+	inpath, err := c.inpath(inpath)
+	if err != nil {
+		return nil, err
+	}
+
+	out, _, err := c.output(ctx, inpath, nil)
+	if failed(err) {
+		return nil, err
+	}
+
+	txt, terr := c.transformBytes(out)
+	if terr != nil {
+		return nil, terr
+	}
 
-	out, err := cmd.Output()
+	return txt, err
+}
+
+// RunWith executes prepared `pdftotext` command with `opts` applied on top of
+// it, for this call only, e.g. to change the page range. The command itself
+// is left untouched.
+//
+// The options are validated as in `NewCommand`, except `WithCustomPath`,
+// which has no effect here.
+func (c *Command) RunWith(ctx context.Context, inpath string, opts ...option) (io.Reader, error) {
+	cc, err := c.with(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return bytes.NewBuffer(out), nil
+	return cc.Run(ctx, inpath)
+}
+
+// with returns a copy of the command with `opts` applied and validated.
+func (c *Command) with(ctx context.Context, opts ...option) (*Command, error) {
+	cc := c.Clone()
+	path := cc.path
+	requires, checks := len(cc.requires), len(cc.checks)
+
+	for _, opt := range opts {
+		opt(cc)
+	}
+	cc.path = path
+
+	if err := cc.validate(); err != nil {
+		return nil, err
+	}
+	for _, req := range cc.requires[requires:] {
+		if err := cc.require(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	for _, check := range cc.checks[checks:] {
+		if err := check(cc); err != nil {
+			return nil, err
+		}
+	}
+	if err := cc.chdir(); err != nil {
+		return nil, err
+	}
+
+	return cc, nil
+}
+
+// run executes prepared `pdftotext` command for `inpath`, reading the input
+// from `stdin` if `inpath` is "-".
+func (c *Command) run(ctx context.Context, inpath string, stdin io.Reader) (io.Reader, error) {
+	out, _, err := c.output(ctx, inpath, stdin)
+	if failed(err) {
+		return nil, err
+	}
+
+	return c.transform(bytes.NewBuffer(out)), err
+}
+
+// output executes prepared `pdftotext` command as in `run`, and returns the
+// output before transforms, along with the warnings. With `ErrTooManyPages`
+// the output is returned too, and on failure the output produced so far.
+func (c *Command) output(ctx context.Context, inpath string, stdin io.Reader) ([]byte, []string, error) {
+	out, stderr, err := c.exec(ctx, inpath, stdin)
+	if err != nil {
+		return out, nil, err
+	}
+
+	warns := c.warn(stderr)
+	if err := c.strictFonts(warns); err != nil {
+		return out, warns, err
+	}
+
+	// page n+1 was converted, so the document has more than n pages
+	if c.maxPages > 0 && uint64(bytes.Count(out, []byte(pageBreak))) > c.maxPages {
+		return out[:nthIndex(out, []byte(pageBreak), int(c.maxPages))+1], warns, ErrTooManyPages
+	}
+
+	return out, warns, nil
 }
 
-// String returns a human-readable description of the command.
+// failed reports whether `err` means the conversion failed. With
+// `ErrTooManyPages` the output is still usable.
+func failed(err error) bool {
+	return err != nil && err != ErrTooManyPages
+}
+
+// transformBytes applies output transforms of the command to `out`.
+func (c *Command) transformBytes(out []byte) ([]byte, error) {
+	if len(c.transforms) == 0 && !c.transcode && !c.stripBOM {
+		return out, nil
+	}
+
+	return io.ReadAll(c.transform(bytes.NewReader(out)))
+}
+
+// exec executes `pdftotext` with the command's arguments for `inpath`, and
+// returns the raw standard output and error.
+func (c *Command) exec(ctx context.Context, inpath string, stdin io.Reader) ([]byte, []byte, error) {
+	ctx, cancel := c.timeout(ctx)
+	defer cancel()
+
+	args, cleanup, err := c.prepare(ctx, inpath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cleanup()
+
+	var stdout bytes.Buffer
+	stderr, err := c.execArgs(ctx, inpath, args, stdin, &stdout)
+
+	return stdout.Bytes(), stderr, err
+}
+
+// tool executes `pdftotext` with `args` in place of the command's arguments,
+// e.g. "-v", and returns the standard output and error.
+func (c *Command) tool(ctx context.Context, args ...string) ([]byte, []byte, error) {
+	ctx, cancel := c.timeout(ctx)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	stderr, err := c.execArgs(ctx, "", args, nil, &stdout)
+
+	return stdout.Bytes(), stderr, err
+}
+
+// execArgs executes `pdftotext` with `args` through the command's runner, in
+// its directory and environment, writing the standard output to `stdout`, and
+// returns the standard error. If the context is done, its error is returned
+// wrapped with the command. A non-zero exit code is returned as
+// `*CommandError`.
+//
+// A non-empty `inpath` marks a conversion of it, which is recorded.
+func (c *Command) execArgs(ctx context.Context, inpath string, args []string, stdin io.Reader, stdout io.Writer) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, c.path, args...)
+	cmd.Dir = c.dir
+	cmd.Env = c.env
+	setProcessGroup(cmd)
+
+	if c.niceness != 0 {
+		if err := setNiceness(cmd, c.niceness); err != nil {
+			return nil, err
+		}
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	// passwords of conversions are redacted
+	command := cmd.String()
+
+	var rec *recording
+	if inpath != "" {
+		command = c.command(inpath)
+		if rec = c.startRecording(cmd, inpath); rec != nil {
+			cmd.Stdout = io.MultiWriter(stdout, &rec.stdout)
+		}
+	}
+
+	err := processError(ctx, command, c.runner.Run(ctx, cmd), stderr.Bytes())
+	c.stopRecording(ctx, rec, cmd, err)
+
+	return stderr.Bytes(), err
+}
+
+// timeout returns `ctx` with the timeout set with `WithTimeout`, if any.
+func (c *Command) timeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeoutAfter > 0 {
+		return context.WithTimeout(ctx, c.timeoutAfter)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// ErrTooManyPages is returned when the document exceeds the page limit set by
+// `WithMaxPagesFast`, along with the output, or the pages, up to the limit.
+var ErrTooManyPages = errors.New("pdftotext: too many pages")
+
+// nthIndex returns the index of the n-th occurrence of `sep` in `s`.
+func nthIndex(s, sep []byte, n int) int {
+	i := -len(sep)
+	for ; n > 0; n-- {
+		j := bytes.Index(s[i+len(sep):], sep)
+		if j < 0 {
+			return -1
+		}
+		i += len(sep) + j
+	}
+
+	return i
+}
+
+// inpath returns the input path as seen from the command's directory.
+func (c *Command) inpath(inpath string) (string, error) {
+	if c.dir == c.workDir {
+		return inpath, nil
+	}
+
+	return filepath.Abs(c.resolve(inpath))
+}
+
+// resolve returns `path` relative to the working directory set with
+// `WithWorkingDir`, if any.
+func (c *Command) resolve(path string) string {
+	if c.workDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(c.workDir, path)
+}
+
+// Clone returns a deep copy of the command, so options applied to the copy
+// don't affect the original.
+func (c *Command) Clone() *Command {
+	cc := *c
+	cc.args = slices.Clone(c.args)
+	cc.flags = maps.Clone(c.flags)
+	cc.env = slices.Clone(c.env)
+	cc.secrets = slices.Clone(c.secrets)
+	cc.lazy = slices.Clone(c.lazy)
+	cc.transforms = slices.Clone(c.transforms)
+	cc.skips = slices.Clone(c.skips)
+	cc.modes = slices.Clone(c.modes)
+	cc.errs = slices.Clone(c.errs)
+	cc.checks = slices.Clone(c.checks)
+	cc.requires = slices.Clone(c.requires)
+
+	return &cc
+}
+
+// withArgs returns a copy of the command with `args` appended.
+func (c *Command) withArgs(args ...string) *Command {
+	cc := c.Clone()
+	cc.args = append(cc.args, args...)
+
+	return cc
+}
+
+// Args returns the full argument list, starting with the executable path, that
+// is executed to convert `inpath`, e.g. for logging or dry runs.
+func (c *Command) Args(inpath string) []string {
+	return append([]string{c.path}, c.operands(inpath)...)
+}
+
+// operands returns arguments of the command followed by the input and output
+// operands. The arguments are copied, so the command is never modified.
+//
+// An input path starting with a dash is prefixed with "./", so it's not taken
+// for an option. A lone dash still means standard input.
+func (c *Command) operands(inpath string) []string {
+	args := make([]string, 0, len(c.args)+2)
+	args = append(args, c.args...)
+
+	if inpath != "-" && strings.HasPrefix(inpath, "-") {
+		inpath = "./" + inpath
+	}
+
+	return append(args, inpath, "-")
+}
+
+// redacted returns operands as in `operands`, with secrets, e.g. passwords,
+// replaced with "***".
+func (c *Command) redacted(inpath string) []string {
+	args := c.operands(inpath)
+	for _, i := range c.secrets {
+		args[i] = "***"
+	}
+
+	return args
+}
+
+// String returns a human-readable description of the command, with passwords
+// redacted.
 func (c *Command) String() string {
-	return exec.Command(c.path, append(c.args, "<inpath>")...).String()
+	return c.command("<inpath>")
+}
+
+// command returns the command converting `inpath`, with passwords redacted,
+// e.g. for errors naming the file.
+func (c *Command) command(inpath string) string {
+	return exec.Command(c.path, c.redacted(inpath)...).String()
+}
+
+// StringWith returns the command executed to convert `inpath`, with passwords
+// redacted, quoted to be pasted into a shell, e.g. to reproduce a failure.
+// The command runs in the directory set with `WithWorkingDir`, if any.
+func (c *Command) StringWith(inpath string) string {
+	if abs, err := c.inpath(inpath); err == nil {
+		inpath = abs
+	}
+
+	args := append([]string{c.path}, c.redacted(inpath)...)
+	for i, arg := range args {
+		args[i] = shellQuote(arg)
+	}
+
+	return strings.Join(args, " ")
+}
+
+// shellQuote quotes `s` for a POSIX shell, if needed.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,+@%") == "" {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 // ----------------------------------------------------------------------------
@@ -65,39 +559,220 @@ func (c *Command) String() string {
 
 type option func(*Command)
 
+// flag sets single-value `flag` to `value`, and returns the index of the value
+// in args. If the flag is already set, its value is replaced in place, so the
+// last value wins and the flag is passed once.
+func (c *Command) flag(flag, value string) int {
+	if i, ok := c.flags[flag]; ok {
+		c.args[i] = value
+		// the value is no longer produced at run time
+		c.lazy = slices.DeleteFunc(c.lazy, func(arg lazyArg) bool { return arg.index == i })
+		return i
+	}
+
+	if c.flags == nil {
+		c.flags = make(map[string]int)
+	}
+	c.args = append(c.args, flag, value)
+	c.flags[flag] = len(c.args) - 1
+
+	return len(c.args) - 1
+}
+
+// unflag removes single-value `flag` and its value from args, if set.
+func (c *Command) unflag(flag string) {
+	i, ok := c.flags[flag]
+	if !ok {
+		return
+	}
+
+	c.args = slices.Delete(c.args, i-1, i+1)
+	delete(c.flags, flag)
+
+	// shift indexes of the args after it
+	shift := func(j int) int {
+		if j > i {
+			return j - 2
+		}
+		return j
+	}
+	for f, j := range c.flags {
+		c.flags[f] = shift(j)
+	}
+	c.secrets = slices.DeleteFunc(c.secrets, func(j int) bool { return j == i })
+	for k := range c.secrets {
+		c.secrets[k] = shift(c.secrets[k])
+	}
+	c.lazy = slices.DeleteFunc(c.lazy, func(arg lazyArg) bool { return arg.index == i })
+	for k := range c.lazy {
+		c.lazy[k].index = shift(c.lazy[k].index)
+	}
+	if c.config > 0 {
+		c.config = shift(c.config)
+	}
+}
+
+// secret marks the arg at index `i` to be redacted.
+func (c *Command) secret(i int) {
+	if !slices.Contains(c.secrets, i) {
+		c.secrets = append(c.secrets, i)
+	}
+}
+
+// EOL is an end-of-line convention of text output.
+type EOL string
+
+const (
+	EOLUnix EOL = "unix"
+	EOLDOS  EOL = "dos"
+	EOLMac  EOL = "mac"
+)
+
 // Set custom location for `pdftotext` executable.
+//
+// It's used as is, without searching default locations.
 func WithCustomPath(path string) option {
 	return func(c *Command) {
 		c.path = path
 	}
 }
 
+// Set the environment of `pdftotext` process, e.g. XPDFRC or LANG, as
+// "key=value" entries. By default the environment of the current process is
+// inherited.
+func WithEnv(env []string) option {
+	return func(c *Command) {
+		c.env = slices.Clone(env)
+	}
+}
+
+// Set a single environment variable of `pdftotext` process, on top of the
+// inherited environment or the one set with `WithEnv`.
+func WithEnvVar(key, value string) option {
+	return func(c *Command) {
+		if c.env == nil {
+			c.env = os.Environ()
+		}
+		c.env = append(c.env, key+"="+value)
+	}
+}
+
+// Run `pdftotext` in `dir`, so relative input and config-file paths resolve
+// against it. `NewCommand` fails if it's not an existing directory.
+//
+// Output always goes to standard output, so it's not affected.
+func WithWorkingDir(dir string) option {
+	return func(c *Command) {
+		c.workDir = dir
+	}
+}
+
 // Read config-file in place of ~/.xpdfrc or the system-wide config file.
 func WithCustomConfig(path string) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-cfg", path)
+		c.config = c.flag("-cfg", path)
 	}
 }
 
-// Specifies the first page to convert.
+// Choose how relative paths inside the config-file (fonts, encodings, etc.)
+// are resolved.
+//
+// By default they are resolved against the working directory. When enabled,
+// they are resolved against the config-file's directory instead, by running
+// `pdftotext` from that directory. Input paths are made absolute, so they
+// still resolve against the working directory, or the one set with
+// `WithWorkingDir`.
+func WithConfigRelativePaths(enabled bool) option {
+	return func(c *Command) {
+		c.configRelative = enabled
+	}
+}
+
+// Specifies the first page to convert. Pages are numbered from 1.
 func WithPageFrom(page uint64) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-f", strconv.FormatUint(page, 10))
+		if page == 0 {
+			c.errs = append(c.errs, errors.New("pdftotext: first page must be at least 1"))
+		}
+
+		c.flag("-f", strconv.FormatUint(page, 10))
+		c.pageFrom = page
 	}
 }
 
-// Specifies the last page to convert.
+// Specifies the last page to convert. Pages are numbered from 1.
 func WithPageTo(page uint64) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-l", strconv.FormatUint(page, 10))
+		if page == 0 {
+			c.errs = append(c.errs, errors.New("pdftotext: last page must be at least 1"))
+		}
+
+		c.flag("-l", strconv.FormatUint(page, 10))
+		c.pageTo = page
 	}
 }
 
 // Specifies the range of pages to convert.
 func WithPageRange(from, to uint64) option {
 	return func(c *Command) {
-		WithPageFrom(from)
-		WithPageTo(to)
+		WithPageFrom(from)(c)
+		WithPageTo(to)(c)
+	}
+}
+
+// Convert all pages, removing the page range set before, e.g. by a reused
+// profile or with `WithPageRange`.
+func WithAllPages() option {
+	return func(c *Command) {
+		c.unflag("-f")
+		c.unflag("-l")
+		c.pageFrom, c.pageTo = 0, 0
+	}
+}
+
+// Convert only the first page, e.g. for previews. It is the same as
+// `WithPageRange(1, 1)`, so `RunPages` returns a single page.
+func WithFirstPageOnly() option {
+	return WithPageRange(1, 1)
+}
+
+// Omit leading blank pages from the output.
+//
+// Pages are identified by page breaks, so it requires them to be present.
+func WithSkipLeadingBlankPages() option {
+	return WithSkipLeadingPages(IsBlankPage, nil)
+}
+
+// Omit leading pages from the output for as long as `skip` reports true for
+// them, e.g. `IsBlankPage` or `IsSparsePage` for cover pages. If `report` is
+// not nil, it is called with the number of skipped pages.
+//
+// Pages are identified by page breaks, so with `WithNoPageBreak` the whole
+// output is treated as a single page.
+func WithSkipLeadingPages(skip func(page string) bool, report func(skipped int)) option {
+	return func(c *Command) {
+		c.skips = append(c.skips, leadingSkip{at: len(c.transforms), skip: skip, report: report})
+		c.transforms = append(c.transforms, skipLeadingPages(skip, report))
+	}
+}
+
+// Fail with `ErrTooManyPages` if the document has more than `n` pages, at
+// least 1.
+//
+// It converts pages up to n+1 and checks whether page n+1 was produced, so no
+// separate page count is needed. The output of the first `n` pages is still
+// returned along with the error; streamed output stops after it, and the
+// command is killed. Requires page breaks and counts pages from the first
+// page of the document, so don't combine it with `WithNoPageBreak` or
+// `WithPageFrom`.
+func WithMaxPagesFast(n uint64) option {
+	return func(c *Command) {
+		if n == 0 {
+			c.errs = append(c.errs, errors.New("pdftotext: max pages must be at least 1"))
+		}
+
+		c.flag("-l", strconv.FormatUint(n+1, 10))
+		c.maxPages = n
 	}
 }
 
@@ -105,6 +780,7 @@ func WithPageRange(from, to uint64) option {
 func WithModeLayout() option {
 	return func(c *Command) {
 		c.args = append(c.args, "-layout")
+		c.modes = append(c.modes, ModeLayout)
 	}
 }
 
@@ -115,15 +791,19 @@ func WithModeLayout() option {
 func WithModeSimple() option {
 	return func(c *Command) {
 		c.args = append(c.args, "-simple")
+		c.modes = append(c.modes, ModeSimple)
 	}
 }
 
 // Similar to `WithModeSimple` but handles slightly rotated text better.
 //
-// Only works for pages with a single column of text.
+// Only works for pages with a single column of text. Supported only by Xpdf;
+// `NewCommand` fails for Poppler.
 func WithModeSimple2() option {
 	return func(c *Command) {
 		c.args = append(c.args, "-simple2")
+		c.requires = append(c.requires, requirement{"-simple2", VariantXpdf})
+		c.modes = append(c.modes, ModeSimple2)
 	}
 }
 
@@ -136,6 +816,7 @@ func WithModeSimple2() option {
 func WithModeTable() option {
 	return func(c *Command) {
 		c.args = append(c.args, "-table")
+		c.modes = append(c.modes, ModeTable)
 	}
 }
 
@@ -149,9 +830,13 @@ func WithModeTable() option {
 // Use `WithCharFixedWidth` and `WithLineFixedSpacing` to specify grid spacing.
 // If one or both are not given on the command line, it will attempt to compute
 // appropriate value(s).
+//
+// Supported only by Xpdf; `NewCommand` fails for Poppler.
 func WithModeLinePrinter() option {
 	return func(c *Command) {
 		c.args = append(c.args, "-lineprinter")
+		c.requires = append(c.requires, requirement{"-lineprinter", VariantXpdf})
+		c.modes = append(c.modes, ModeLinePrinter)
 	}
 }
 
@@ -161,6 +846,7 @@ func WithModeLinePrinter() option {
 func WithModeRaw() option {
 	return func(c *Command) {
 		c.args = append(c.args, "-raw")
+		c.modes = append(c.modes, ModeRaw)
 	}
 }
 
@@ -169,7 +855,8 @@ func WithModeRaw() option {
 // Works only with `WithModeLayout`, `WithModeTable` and `WithModeLinePrinter`.
 func WithCharFixedWidth(width uint64) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-fixed", strconv.FormatUint(width, 10))
+		c.flag("-fixed", strconv.FormatUint(width, 10))
+		c.fixed = true
 	}
 }
 
@@ -178,7 +865,8 @@ func WithCharFixedWidth(width uint64) option {
 // Works only with `WithModeLinePrinter`.
 func WithLineFixedSpacing(spacing uint64) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-linespacing", strconv.FormatUint(spacing, 10))
+		c.flag("-linespacing", strconv.FormatUint(spacing, 10))
+		c.lineSpacing = true
 	}
 }
 
@@ -211,40 +899,261 @@ func WithNoTextDiagonal() option {
 // Available options: `pdftotext -listencodings`.
 func WithEncoding(name string) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-enc", name)
+		c.flag("-enc", name)
+		c.encoding = name
+	}
+}
+
+// Same as `WithEncoding`, but `NewCommand` fails if the encoding is not
+// supported by the executable, suggesting the closest supported one.
+//
+// It runs `pdftotext -listencodings` while creating the command.
+func WithValidatedEncoding(ctx context.Context, name string) option {
+	return func(c *Command) {
+		WithEncoding(name)(c)
+		c.checks = append(c.checks, func(c *Command) error {
+			return c.checkEncoding(ctx, name)
+		})
+	}
+}
+
+// Convert the output from the encoding set with `WithEncoding`, "Latin1" by
+// default, into UTF-8, before any other transforms.
+//
+// With `WithByteOrderMarker`, the output starts with a single UTF-8 BOM, in
+// place of the BOM `pdftotext` writes in the source encoding, if any, e.g.
+// none for "Latin1". `WithUnicodeBOMStripping` still removes it, as it's
+// applied after transcoding.
+//
+// `NewCommand` fails if the encoding can't be decoded, e.g. "Symbol".
+func WithTranscodeToUTF8() option {
+	return func(c *Command) {
+		c.transcode = true
 	}
 }
 
 // Sets the end-of-line convention to use for text output.
 //
-// Available options: "unix", "dos", "mac".
-func WithEndOfLine(kind string) option {
+// Available options: `EOLUnix`, `EOLDOS`, `EOLMac`. Any other value makes
+// `NewCommand` fail.
+func WithEndOfLine(kind EOL) option {
+	return func(c *Command) {
+		switch kind {
+		case EOLUnix, EOLDOS, EOLMac:
+			c.flag("-eol", string(kind))
+		default:
+			c.errs = append(c.errs, fmt.Errorf("pdftotext: unknown end-of-line convention %q", kind))
+		}
+	}
+}
+
+// Apply `transforms` to the output, in order.
+//
+// Transforms are chained, so the output is still read in a single pass.
+func WithTransforms(transforms ...Transform) option {
+	return func(c *Command) {
+		c.transforms = append(c.transforms, transforms...)
+	}
+}
+
+// Run `pdftotext` with niceness `n`, from -20 to 19, e.g. 10 to let batch
+// conversions yield CPU to latency-sensitive work. Negative values, raising
+// the priority, usually require privileges.
+//
+// On Unix it runs `pdftotext` through the `nice` command, and `NewCommand`
+// fails if it's not found. On other systems, e.g. Windows, it has no effect,
+// and `pdftotext` runs with the default priority.
+func WithNiceness(n int) option {
+	return func(c *Command) {
+		if n < -20 || n > 19 {
+			c.errs = append(c.errs, fmt.Errorf("pdftotext: niceness must be from -20 to 19, got %d", n))
+		}
+
+		c.niceness = n
+		c.checks = append(c.checks, func(c *Command) error {
+			// assert that it can be applied, e.g. `nice` is found
+			return setNiceness(exec.Command(c.path), n)
+		})
+	}
+}
+
+// Call `fn` with each warning of a successful `pdftotext` run, e.g. "Syntax
+// Warning: ...", one per line of its standard error. Warnings aren't errors,
+// the output is returned as usual. `RunResult` also returns them.
+//
+// `fn` may be called from multiple goroutines at once.
+func WithOnWarning(fn func(warning string)) option {
+	return func(c *Command) {
+		c.onWarning = fn
+	}
+}
+
+// Fail with `ErrFontWarnings` when `pdftotext` warns about fonts, e.g. of
+// a non-embedded font it couldn't find, as the extracted characters may then
+// be wrong. Without it, such warnings are only reported, e.g. in
+// `Result.FontWarnings`.
+//
+// Streamed output is passed on before the warnings are known, so the error is
+// returned once the output ends.
+func WithStrictFonts() option {
+	return func(c *Command) {
+		c.failOnFonts = true
+	}
+}
+
+// Sets how output is chunked by the streaming variants, e.g. `RunFunc`.
+//
+// Defaults to `BlockBuffering`.
+func WithStreamBuffering(buffering StreamBuffering) option {
+	return func(c *Command) {
+		c.buffering = buffering
+	}
+}
+
+// Sets the hash used for the input by `RunFromBytesWithHash`.
+//
+// Defaults to SHA-256.
+func WithInputHash(h func() hash.Hash) option {
+	return func(c *Command) {
+		c.hash = h
+	}
+}
+
+// Kill `pdftotext` if it runs longer than `d`, as with a context deadline.
+// The error then matches `context.DeadlineExceeded`.
+//
+// It applies on top of the context passed to each run, so whichever deadline
+// comes first wins.
+func WithTimeout(d time.Duration) option {
+	return func(c *Command) {
+		c.timeoutAfter = d
+	}
+}
+
+// Call `fn` after each conversion by `pdftotext`, also a failed one, e.g. to
+// emit traces or metrics. Runs querying the executable, e.g. `pdftotext -v`
+// to detect its variant, aren't conversions and aren't observed.
+//
+// It's called synchronously, so it should be quick. If it panics, the panic
+// is ignored.
+func WithObserver(fn func(info ExecInfo)) option {
+	return func(c *Command) {
+		c.observer = fn
+	}
+}
+
+// Log each conversion by `pdftotext` with `logger`: the command, with
+// passwords redacted, the duration and the exit code. Successful conversions
+// are logged at debug level, failed ones at error level. As with
+// `WithObserver`, runs querying the executable aren't logged.
+func WithLogger(logger *slog.Logger) option {
+	return func(c *Command) {
+		c.logger = logger
+	}
+}
+
+// Execute `pdftotext` with `r`, e.g. a fake in tests, instead of starting a
+// real process.
+func WithRunner(r Runner) option {
+	return func(c *Command) {
+		c.runner = r
+	}
+}
+
+// Record each conversion by `pdftotext` with `r`, e.g. a `MemoryRecorder`.
+// As with `WithObserver`, runs querying the executable aren't recorded.
+//
+// Passwords are redacted in recorded arguments.
+func WithRecorder(r Recorder) option {
+	return func(c *Command) {
+		c.recorder = r
+	}
+}
+
+// Prefix every output line with its number, starting at `start`.
+//
+// Use `LineNumbers` with `WithTransforms` to set the width, the separator or
+// to restart numbering on each page.
+func WithLineNumbers(start int) option {
+	return WithTransforms(LineNumbers(LineNumbering{Start: start}))
+}
+
+// Replace characters without Unicode mapping with `r` in the output.
+//
+// The replaced marker is the Unicode replacement character (U+FFFD), which
+// Poppler emits for glyphs it can't map when the encoding is "UTF-8". Xpdf
+// drops such glyphs instead, so there is nothing to replace.
+func WithUnknownCharReplacement(r rune) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-eol", kind)
+		c.transforms = append(c.transforms, func(out io.Reader) io.Reader {
+			return newReplaceReader(out, []byte(string(utf8.RuneError)), []byte(string(r)))
+		})
 	}
 }
 
+// Replace each page break (form feed) in the output with `sep`, e.g. "\n\n".
+//
+// Unlike `WithNoPageBreak`, it keeps pages apart. It's applied to the output
+// text, so helpers splitting the output into pages, e.g. `RunPages`, no
+// longer find page breaks.
+func WithPageSeparator(sep string) option {
+	return WithTransforms(func(r io.Reader) io.Reader {
+		return newReplaceReader(r, []byte(pageBreak), []byte(sep))
+	})
+}
+
 // Don’t insert a page breaks (form feed character) at the end of each page.
 func WithNoPageBreak() option {
 	return func(c *Command) {
 		c.args = append(c.args, "-nopgbrk")
+		c.noPageBreak = true
 	}
 }
 
 // Insert a Unicode byte order marker (BOM) at the start of the text output.
+//
+// The BOM is in the encoding of the output, so with `WithTranscodeToUTF8` it's
+// a UTF-8 one, whatever the encoding set with `WithEncoding`.
 func WithByteOrderMarker() option {
 	return func(c *Command) {
 		c.args = append(c.args, "-bom")
 	}
 }
 
+// Sets the number of non-whitespace characters per page, on average, below
+// which `RunDetectScanned` reports a document as likely scanned.
+//
+// Defaults to 20. It must be positive, or `NewCommand` fails.
+func WithScannedThreshold(chars int) option {
+	return func(c *Command) {
+		if chars <= 0 {
+			c.errs = append(c.errs, fmt.Errorf("pdftotext: scanned threshold must be positive, got %d", chars))
+		}
+
+		c.scannedThreshold = chars
+	}
+}
+
+// Remove a leading UTF-8 or UTF-16 byte order marker (BOM) from the output,
+// e.g. one emitted for the chosen encoding, which downstream parsers can't
+// handle. It's the opposite of `WithByteOrderMarker`.
+//
+// Only the BOM of the output encoding is removed, i.e. "UTF-8" with
+// `WithTranscodeToUTF8`, or the one set with `WithEncoding` otherwise, so
+// output in e.g. "Latin1" is left as is. It's removed before other transforms.
+func WithUnicodeBOMStripping() option {
+	return func(c *Command) {
+		c.stripBOM = true
+	}
+}
+
 // Specifies the left margin, in points.
 //
 // Text in the left margin (i.e., within that many points of the left edge
 // of the page) is discarded.
 func WithMarginLeft(margin uint64) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-marginl", strconv.FormatUint(margin, 10))
+		c.flag("-marginl", strconv.FormatUint(margin, 10))
 	}
 }
 
@@ -254,7 +1163,7 @@ func WithMarginLeft(margin uint64) option {
 // of the page) is discarded.
 func WithMarginRight(margin uint64) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-marginr", strconv.FormatUint(margin, 10))
+		c.flag("-marginr", strconv.FormatUint(margin, 10))
 	}
 }
 
@@ -264,7 +1173,7 @@ func WithMarginRight(margin uint64) option {
 // of the page) is discarded.
 func WithMarginTop(margin uint64) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-margint", strconv.FormatUint(margin, 10))
+		c.flag("-margint", strconv.FormatUint(margin, 10))
 	}
 }
 
@@ -274,17 +1183,75 @@ func WithMarginTop(margin uint64) option {
 // of the page) is discarded.
 func WithMarginBottom(margin uint64) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-marginb", strconv.FormatUint(margin, 10))
+		c.flag("-marginb", strconv.FormatUint(margin, 10))
 	}
 }
 
 // Specifies the margins, in points.
 func WithMargin(t, r, b, l uint64) option {
 	return func(c *Command) {
-		WithMarginTop(t)
-		WithMarginRight(r)
-		WithMarginBottom(b)
-		WithMarginLeft(l)
+		WithMarginTop(t)(c)
+		WithMarginRight(r)(c)
+		WithMarginBottom(b)(c)
+		WithMarginLeft(l)(c)
+	}
+}
+
+// Don't print any messages or errors.
+//
+// A failure is still reported as `*CommandError` with the exit code, even if
+// its standard error is empty.
+func WithQuiet() option {
+	return func(c *Command) {
+		c.args = append(c.args, "-q")
+	}
+}
+
+// Append raw arguments, passed verbatim before the input and output operands.
+//
+// It's an escape hatch for flags without a dedicated option. The arguments
+// aren't validated and may conflict with the ones set by other options.
+func WithArgs(args ...string) option {
+	return func(c *Command) {
+		c.args = append(c.args, args...)
+	}
+}
+
+// Apply `opts` as a single option, in order, exactly as if they were passed
+// directly in its place, e.g. to keep reusable profiles as `[]option`.
+func WithOptions(opts ...option) option {
+	return func(c *Command) {
+		for _, opt := range opts {
+			opt(c)
+		}
+	}
+}
+
+// Specifies the area to extract text from: the top-left corner at (x, y) and
+// the width and height, in pixels.
+//
+// Supported only by Poppler; `NewCommand` fails for Xpdf.
+func WithCropArea(x, y, w, h uint64) option {
+	return func(c *Command) {
+		c.flag("-x", strconv.FormatUint(x, 10))
+		c.flag("-y", strconv.FormatUint(y, 10))
+		c.flag("-W", strconv.FormatUint(w, 10))
+		c.flag("-H", strconv.FormatUint(h, 10))
+		c.requires = append(c.requires, requirement{"-x/-y/-W/-H", VariantPoppler})
+	}
+}
+
+// Use the crop box rather than the media box as the page area, e.g. to leave
+// out text in the bleed area. The media box is used by default.
+//
+// Supported only by Poppler; Xpdf has no such flag, and `NewCommand` fails
+// for it.
+func WithCropBox() option {
+	return func(c *Command) {
+		if !slices.Contains(c.args, "-cropbox") {
+			c.args = append(c.args, "-cropbox")
+		}
+		c.requires = append(c.requires, requirement{"-cropbox", VariantPoppler})
 	}
 }
 
@@ -293,13 +1260,44 @@ func WithMargin(t, r, b, l uint64) option {
 // Providing this will bypass all security restrictions.
 func WithOwnerPassword(password string) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-opw", password)
+		c.secret(c.flag("-opw", password))
 	}
 }
 
 // Specify the user password for the PDF file.
 func WithUserPassword(password string) option {
 	return func(c *Command) {
-		c.args = append(c.args, "-upw", password)
+		c.secret(c.flag("-upw", password))
 	}
 }
+
+// Same as `WithOwnerPassword`, but the password is produced by `fn` for each
+// execution, e.g. fetched from a secret manager. If `fn` fails, the error is
+// returned without starting `pdftotext`.
+func WithOwnerPasswordFunc(fn func(ctx context.Context) (string, error)) option {
+	return func(c *Command) {
+		c.lazyPassword("-opw", fn)
+	}
+}
+
+// Same as `WithUserPassword`, but the password is produced by `fn` for each
+// execution, e.g. fetched from a secret manager. If `fn` fails, the error is
+// returned without starting `pdftotext`.
+func WithUserPasswordFunc(fn func(ctx context.Context) (string, error)) option {
+	return func(c *Command) {
+		c.lazyPassword("-upw", fn)
+	}
+}
+
+// lazyPassword appends `flag` with a password produced by `fn`.
+func (c *Command) lazyPassword(flag string, fn func(ctx context.Context) (string, error)) {
+	i := c.flag(flag, "***")
+	c.secret(i)
+	c.lazy = append(c.lazy, lazyArg{
+		index: i,
+		value: func(ctx context.Context) (string, func(), error) {
+			password, err := fn(ctx)
+			return password, nil, err
+		},
+	})
+}
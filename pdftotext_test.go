@@ -0,0 +1,490 @@
+package pdftotext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// argv returns the arguments of a command created with `opts` to convert
+// "in.pdf", without the executable.
+func argv(t *testing.T, opts ...option) []string {
+	t.Helper()
+
+	return newFake(t, &fakeRunner{}, opts...).Args("in.pdf")[1:]
+}
+
+func TestWithPageRange(t *testing.T) {
+	got := argv(t, WithPageRange(2, 5))
+
+	if want := []string{"-f", "2", "-l", "5", "in.pdf", "-"}; !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
+
+func TestWithPageRangeInvalid(t *testing.T) {
+	for _, r := range [][2]uint64{{0, 1}, {1, 0}, {5, 2}} {
+		if _, err := NewCommand(WithRunner(&fakeRunner{}), WithPageRange(r[0], r[1])); err == nil {
+			t.Errorf("WithPageRange(%d, %d) error = nil, want error", r[0], r[1])
+		}
+	}
+}
+
+func TestWithMargin(t *testing.T) {
+	got := argv(t, WithMargin(1, 2, 3, 4))
+
+	want := []string{"-margint", "1", "-marginr", "2", "-marginb", "3", "-marginl", "4", "in.pdf", "-"}
+	if !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
+
+func TestWithConfigRelativePaths(t *testing.T) {
+	wd := t.TempDir()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     []option
+		wantArgs []string
+		wantDir  string
+	}{
+		{
+			name:     "working directory",
+			opts:     []option{WithCustomConfig("conf/x.cfg")},
+			wantArgs: []string{"-cfg", "conf/x.cfg", "in.pdf", "-"},
+		},
+		{
+			name:     "working directory set",
+			opts:     []option{WithWorkingDir(wd), WithCustomConfig("conf/x.cfg")},
+			wantArgs: []string{"-cfg", "conf/x.cfg", "in.pdf", "-"},
+			wantDir:  wd,
+		},
+		{
+			name:     "config directory",
+			opts:     []option{WithCustomConfig("conf/x.cfg"), WithConfigRelativePaths(true)},
+			wantArgs: []string{"-cfg", filepath.Join(cwd, "conf/x.cfg"), filepath.Join(cwd, "in.pdf"), "-"},
+			wantDir:  filepath.Join(cwd, "conf"),
+		},
+		{
+			name:     "config directory in working directory",
+			opts:     []option{WithWorkingDir(wd), WithCustomConfig("conf/x.cfg"), WithConfigRelativePaths(true)},
+			wantArgs: []string{"-cfg", filepath.Join(wd, "conf/x.cfg"), filepath.Join(wd, "in.pdf"), "-"},
+			wantDir:  filepath.Join(wd, "conf"),
+		},
+		{
+			name:     "absolute config",
+			opts:     []option{WithWorkingDir(wd), WithCustomConfig("/etc/x.cfg"), WithConfigRelativePaths(true)},
+			wantArgs: []string{"-cfg", "/etc/x.cfg", filepath.Join(wd, "in.pdf"), "-"},
+			wantDir:  "/etc",
+		},
+		{
+			name:     "without config",
+			opts:     []option{WithWorkingDir(wd), WithConfigRelativePaths(true)},
+			wantArgs: []string{"in.pdf", "-"},
+			wantDir:  wd,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{}
+			cmd := newFake(t, f, tt.opts...)
+
+			if _, err := cmd.Run(context.Background(), "in.pdf"); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			args, dir := f.last()
+			if !slices.Equal(args, tt.wantArgs) {
+				t.Errorf("args = %q, want %q", args, tt.wantArgs)
+			}
+			if dir != tt.wantDir {
+				t.Errorf("dir = %q, want %q", dir, tt.wantDir)
+			}
+		})
+	}
+}
+
+func TestRunWithDirectories(t *testing.T) {
+	wd, other := t.TempDir(), t.TempDir()
+
+	tests := []struct {
+		name     string
+		base     []option
+		opts     []option
+		wantArgs []string
+		wantDir  string
+	}{
+		{
+			name:     "working directory",
+			base:     []option{WithWorkingDir(wd)},
+			wantArgs: []string{"in.pdf", "-"},
+			wantDir:  wd,
+		},
+		{
+			name:     "working directory replaced",
+			base:     []option{WithWorkingDir(wd)},
+			opts:     []option{WithWorkingDir(other)},
+			wantArgs: []string{"in.pdf", "-"},
+			wantDir:  other,
+		},
+		{
+			name:     "working directory set",
+			opts:     []option{WithWorkingDir(other)},
+			wantArgs: []string{"in.pdf", "-"},
+			wantDir:  other,
+		},
+		{
+			name:     "config-relative",
+			base:     []option{WithWorkingDir(wd), WithConfigRelativePaths(true), WithCustomConfig("a/x.cfg")},
+			wantArgs: []string{"-cfg", filepath.Join(wd, "a/x.cfg"), filepath.Join(wd, "in.pdf"), "-"},
+			wantDir:  filepath.Join(wd, "a"),
+		},
+		{
+			name:     "config-relative config replaced",
+			base:     []option{WithWorkingDir(wd), WithConfigRelativePaths(true), WithCustomConfig("a/x.cfg")},
+			opts:     []option{WithCustomConfig("b/y.cfg")},
+			wantArgs: []string{"-cfg", filepath.Join(wd, "b/y.cfg"), filepath.Join(wd, "in.pdf"), "-"},
+			wantDir:  filepath.Join(wd, "b"),
+		},
+		{
+			name:     "config-relative enabled",
+			base:     []option{WithWorkingDir(wd), WithCustomConfig("a/x.cfg")},
+			opts:     []option{WithConfigRelativePaths(true)},
+			wantArgs: []string{"-cfg", filepath.Join(wd, "a/x.cfg"), filepath.Join(wd, "in.pdf"), "-"},
+			wantDir:  filepath.Join(wd, "a"),
+		},
+		{
+			name:     "config-relative disabled",
+			base:     []option{WithWorkingDir(wd), WithConfigRelativePaths(true), WithCustomConfig("a/x.cfg")},
+			opts:     []option{WithConfigRelativePaths(false), WithCustomConfig("b/y.cfg")},
+			wantArgs: []string{"-cfg", "b/y.cfg", "in.pdf", "-"},
+			wantDir:  wd,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{}
+			cmd := newFake(t, f, tt.base...)
+			before := cmd.Args("in.pdf")
+
+			if _, err := cmd.RunWith(context.Background(), "in.pdf", tt.opts...); err != nil {
+				t.Fatalf("RunWith() error = %v", err)
+			}
+
+			args, dir := f.last()
+			if !slices.Equal(args, tt.wantArgs) {
+				t.Errorf("args = %q, want %q", args, tt.wantArgs)
+			}
+			if dir != tt.wantDir {
+				t.Errorf("dir = %q, want %q", dir, tt.wantDir)
+			}
+
+			if after := cmd.Args("in.pdf"); !slices.Equal(after, before) {
+				t.Errorf("command changed to %q, was %q", after, before)
+			}
+		})
+	}
+}
+
+func TestRunWithInvalidWorkingDir(t *testing.T) {
+	cmd := newFake(t, &fakeRunner{})
+
+	if _, err := cmd.RunWith(context.Background(), "in.pdf", WithWorkingDir(filepath.Join(t.TempDir(), "missing"))); err == nil {
+		t.Fatal("RunWith() error = nil, want error")
+	}
+}
+
+func TestWithArgs(t *testing.T) {
+	got := argv(t, WithModeLayout(), WithArgs("-nodiag", "-x", "10"), WithPageFrom(2))
+
+	want := []string{"-layout", "-nodiag", "-x", "10", "-f", "2", "in.pdf", "-"}
+	if !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+
+	// also for input from standard input
+	cmd := newFake(t, &fakeRunner{}, WithArgs("-nodiag"))
+	if got, want := cmd.Args("-")[1:], []string{"-nodiag", "-", "-"}; !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
+
+func TestWithQuiet(t *testing.T) {
+	if got, want := argv(t, WithQuiet()), []string{"-q", "in.pdf", "-"}; !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+
+	// a failure surfaces without any message
+	cmd := newFake(t, &fakeRunner{respond: func([]string) fakeResult {
+		return fakeResult{code: 1}
+	}}, WithQuiet())
+
+	_, err := cmd.Run(context.Background(), "in.pdf")
+
+	var ce *CommandError
+	if !errors.As(err, &ce) || ce.ExitCode != 1 || !errors.Is(err, ErrOpenPDF) {
+		t.Errorf("Run() error = %v, want *CommandError with exit code 1", err)
+	}
+}
+
+func TestClone(t *testing.T) {
+	cmd := newFake(t, &fakeRunner{}, WithEncoding("UTF-8"), WithPageRange(1, 3), WithOwnerPassword("s3cret"))
+	before := cmd.Args("in.pdf")
+
+	cc := cmd.Clone()
+	WithEncoding("Latin1")(cc)
+	WithAllPages()(cc)
+	WithModeLayout()(cc)
+	WithTransforms(StripBOM("UTF-8"))(cc)
+	cc.args[0] = "-changed"
+
+	if got := cmd.Args("in.pdf"); !slices.Equal(got, before) {
+		t.Errorf("original args = %q, want %q", got, before)
+	}
+	if len(cmd.modes) != 0 || len(cmd.transforms) != 0 {
+		t.Errorf("original modes = %q, transforms = %d, want none", cmd.modes, len(cmd.transforms))
+	}
+	if !strings.Contains(cmd.String(), "***") || strings.Contains(cmd.String(), "s3cret") {
+		t.Errorf("original String() = %q, want password redacted", cmd.String())
+	}
+
+	want := []string{"-changed", "Latin1", "-opw", "s3cret", "-layout", "in.pdf", "-"}
+	if got := cc.Args("in.pdf")[1:]; !slices.Equal(got, want) {
+		t.Errorf("clone args = %q, want %q", got, want)
+	}
+}
+
+func TestRunConcurrent(t *testing.T) {
+	f := &fakeRunner{respond: func(args []string) fakeResult {
+		return fakeResult{stdout: args[len(args)-2]}
+	}}
+	cmd := newFake(t, f, WithEncoding("UTF-8"), WithPageRange(1, 2))
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			inpath := fmt.Sprintf("in%d.pdf", i)
+			out, err := cmd.RunString(context.Background(), inpath)
+			if err != nil {
+				t.Errorf("RunString() error = %v", err)
+			}
+			if out != inpath {
+				t.Errorf("RunString() = %q, want %q", out, inpath)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, args := range f.conversions() {
+		if want := []string{"-enc", "UTF-8", "-f", "1", "-l", "2"}; !slices.Equal(args[:6], want) || len(args) != 8 {
+			t.Errorf("args = %q, want %q and operands", args, want)
+		}
+	}
+}
+
+func TestInputOperand(t *testing.T) {
+	tests := []struct {
+		inpath string
+		want   string
+	}{
+		{"in.pdf", "in.pdf"},
+		{"-foo.pdf", "./-foo.pdf"},
+		{"--help", "./--help"},
+		{"-", "-"},
+		{"/tmp/-foo.pdf", "/tmp/-foo.pdf"},
+		{"my file -f 1.pdf", "my file -f 1.pdf"},
+		{"dir/-x.pdf", "dir/-x.pdf"},
+	}
+
+	for _, tt := range tests {
+		f := &fakeRunner{}
+		cmd := newFake(t, f, WithPageFrom(2))
+
+		if _, err := cmd.Run(context.Background(), tt.inpath); err != nil {
+			t.Fatalf("Run(%q) error = %v", tt.inpath, err)
+		}
+
+		args, _ := f.last()
+		if want := []string{"-f", "2", tt.want, "-"}; !slices.Equal(args, want) {
+			t.Errorf("Run(%q) args = %q, want %q", tt.inpath, args, want)
+		}
+	}
+}
+
+func TestPasswordRedaction(t *testing.T) {
+	f := &fakeRunner{respond: func([]string) fakeResult {
+		return fakeResult{stderr: "Syntax Error: broken", code: 1}
+	}}
+	cmd := newFake(t, f,
+		WithOwnerPassword("own3r"),
+		WithUserPassword("us3r"),
+		WithUserPassword("us3r-2"),
+		WithPageFrom(2),
+	)
+
+	_, runErr := cmd.Run(context.Background(), "in.pdf")
+	if runErr == nil {
+		t.Fatal("Run() error = nil, want failure")
+	}
+
+	// the passwords are passed to the process
+	if args, _ := f.last(); !slices.Contains(args, "own3r") || !slices.Contains(args, "us3r-2") {
+		t.Errorf("args = %q, want passwords", args)
+	}
+
+	info, err := cmd.ReproInfo(context.Background())
+	if err != nil {
+		t.Fatalf("ReproInfo() error = %v", err)
+	}
+
+	outputs := map[string]string{
+		"String":     cmd.String(),
+		"StringWith": cmd.StringWith("in.pdf"),
+		"Run error":  runErr.Error(),
+		"ReproInfo":  info.Command + strings.Join(info.Args, " "),
+		"Config":     strings.Join(cmd.Config().Args, " "),
+	}
+	for name, out := range outputs {
+		if strings.Contains(out, "own3r") || strings.Contains(out, "us3r") {
+			t.Errorf("%s = %q, want passwords redacted", name, out)
+		}
+	}
+
+	if want := "-opw *** -upw *** -f 2"; !strings.Contains(cmd.String(), want) {
+		t.Errorf("String() = %q, want %q", cmd.String(), want)
+	}
+}
+
+func TestWithOptions(t *testing.T) {
+	profile := []option{WithEncoding("UTF-8"), WithModeLayout(), WithPageFrom(2)}
+	extra := []option{WithQuiet(), WithEncoding("Latin1"), WithArgs("-nodiag")}
+
+	direct := argv(t, append(append([]option{WithCropBox()}, profile...), extra...)...)
+	grouped := argv(t, WithCropBox(), WithOptions(profile...), WithOptions(extra...))
+	nested := argv(t, WithOptions(WithCropBox(), WithOptions(profile...)), WithOptions(extra...))
+
+	want := []string{"-cropbox", "-enc", "Latin1", "-layout", "-f", "2", "-q", "-nodiag", "in.pdf", "-"}
+	for name, got := range map[string][]string{"direct": direct, "grouped": grouped, "nested": nested} {
+		if !slices.Equal(got, want) {
+			t.Errorf("%s args = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSingleValueFlags(t *testing.T) {
+	got := argv(t,
+		WithEncoding("UTF-8"), WithEndOfLine(EOLDOS), WithPageRange(1, 2), WithModeLayout(),
+		WithCharFixedWidth(3), WithOwnerPassword("a"), WithUserPassword("b"), WithMargin(1, 1, 1, 1),
+		WithOptions(
+			WithEncoding("Latin1"), WithEndOfLine(EOLUnix), WithPageRange(3, 4),
+			WithCharFixedWidth(5), WithOwnerPassword("c"), WithUserPassword("d"), WithMargin(2, 2, 2, 2),
+		),
+		WithCustomConfig("a.cfg"), WithCustomConfig("b.cfg"),
+	)
+
+	want := []string{
+		"-enc", "Latin1", "-eol", "unix", "-f", "3", "-l", "4", "-layout", "-fixed", "5",
+		"-opw", "c", "-upw", "d", "-margint", "2", "-marginr", "2", "-marginb", "2", "-marginl", "2",
+		"-cfg", "b.cfg", "in.pdf", "-",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+
+	seen := map[string]bool{}
+	for _, arg := range got {
+		if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			if seen[arg] {
+				t.Errorf("flag %s repeated in %q", arg, got)
+			}
+			seen[arg] = true
+		}
+	}
+}
+
+func TestWithAllPages(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []option
+		want []string
+	}{
+		{"range", []option{WithPageRange(2, 5), WithAllPages()}, []string{"in.pdf", "-"}},
+		{"first page", []option{WithFirstPageOnly(), WithAllPages()}, []string{"in.pdf", "-"}},
+		{
+			"other flags",
+			[]option{WithEncoding("UTF-8"), WithPageRange(2, 5), WithUserPassword("s3cret"), WithAllPages(), WithModeRaw()},
+			[]string{"-enc", "UTF-8", "-upw", "s3cret", "-raw", "in.pdf", "-"},
+		},
+		{"range again", []option{WithPageRange(2, 5), WithAllPages(), WithPageTo(3)}, []string{"-l", "3", "in.pdf", "-"}},
+		{"no range", []option{WithAllPages()}, []string{"in.pdf", "-"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newFake(t, &fakeRunner{}, tt.opts...)
+
+			if got := cmd.Args("in.pdf")[1:]; !slices.Equal(got, tt.want) {
+				t.Errorf("args = %q, want %q", got, tt.want)
+			}
+			// the password is still redacted at its shifted index
+			if strings.Contains(cmd.String(), "s3cret") {
+				t.Errorf("String() = %q, want password redacted", cmd.String())
+			}
+		})
+	}
+}
+
+func TestWithCropBox(t *testing.T) {
+	if got, want := argv(t, WithCropBox(), WithCropBox()), []string{"-cropbox", "in.pdf", "-"}; !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+
+	_, err := NewCommand(WithRunner(&fakeRunner{banner: xpdfBanner}), WithCropBox())
+	if err == nil || !strings.Contains(err.Error(), "-cropbox is supported only by") {
+		t.Errorf("NewCommand() with Xpdf error = %v, want unsupported -cropbox", err)
+	}
+
+	// the variant is checked for options of a single run too
+	cmd := newFake(t, &fakeRunner{banner: xpdfBanner})
+	if _, err := cmd.RunWith(context.Background(), "in.pdf", WithCropBox()); err == nil {
+		t.Error("RunWith() with Xpdf error = nil, want unsupported -cropbox")
+	}
+}
+
+func TestWithCropArea(t *testing.T) {
+	want := []string{"-x", "10", "-y", "20", "-W", "300", "-H", "400", "in.pdf", "-"}
+	if got := argv(t, WithCropArea(10, 20, 300, 400)); !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+
+	// applied again, the area is replaced in place
+	want = []string{"-x", "1", "-y", "2", "-W", "3", "-H", "4", "-layout", "in.pdf", "-"}
+	if got := argv(t, WithCropArea(10, 20, 300, 400), WithModeLayout(), WithCropArea(1, 2, 3, 4)); !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+
+	_, err := NewCommand(WithRunner(&fakeRunner{banner: xpdfBanner}), WithCropArea(10, 20, 300, 400))
+	if err == nil || !strings.Contains(err.Error(), "-x/-y/-W/-H is supported only by") {
+		t.Errorf("NewCommand() with Xpdf error = %v, want unsupported -x/-y/-W/-H", err)
+	}
+
+	cmd := newFake(t, &fakeRunner{banner: xpdfBanner})
+	if _, err := cmd.RunWith(context.Background(), "in.pdf", WithCropArea(10, 20, 300, 400)); err == nil {
+		t.Error("RunWith() with Xpdf error = nil, want unsupported -x/-y/-W/-H")
+	}
+}
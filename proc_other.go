@@ -0,0 +1,15 @@
+//go:build !unix
+
+package pdftotext
+
+import "os/exec"
+
+// setProcessGroup does nothing, process groups are specific to Unix. On
+// cancellation only `pdftotext` itself is killed.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// setNiceness does nothing, niceness is specific to Unix. `pdftotext` runs
+// with the default priority.
+func setNiceness(cmd *exec.Cmd, n int) error {
+	return nil
+}
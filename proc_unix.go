@@ -0,0 +1,32 @@
+//go:build unix
+
+package pdftotext
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// setProcessGroup starts `pdftotext` in its own process group, and makes
+// cancellation kill the whole group, so no helper process survives.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// setNiceness makes `cmd` run with niceness `n`, through the `nice` command,
+// as there's no way to set it before the process starts otherwise.
+func setNiceness(cmd *exec.Cmd, n int) error {
+	path, err := exec.LookPath("nice")
+	if err != nil {
+		return err
+	}
+
+	cmd.Args = append([]string{path, "-n", strconv.Itoa(n), cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = path
+
+	return nil
+}
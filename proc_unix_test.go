@@ -0,0 +1,214 @@
+//go:build unix
+
+package pdftotext
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeExecutable creates an executable shell script named "pdftotext" with
+// `script` as its body, and returns its path.
+func fakeExecutable(t *testing.T, script string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "pdftotext")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// alive reports whether process `pid` is running, and not a zombie.
+func alive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true // no procfs, e.g. on macOS
+	}
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+func TestCancelKillsProcessGroup(t *testing.T) {
+	pids := filepath.Join(t.TempDir(), "pids")
+
+	// a helper process is left running in the background
+	path := fakeExecutable(t, `sleep 30 &
+echo $$ $! > `+pids+`.tmp && mv `+pids+`.tmp `+pids+`
+wait
+`)
+
+	cmd, err := NewCommand(WithCustomPath(path))
+	if err != nil {
+		t.Fatalf("NewCommand() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := cmd.Run(ctx, "in.pdf")
+		done <- err
+	}()
+
+	var out []byte
+	for deadline := time.Now().Add(10 * time.Second); len(out) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("the process didn't start")
+		}
+		time.Sleep(10 * time.Millisecond)
+		out, _ = os.ReadFile(pids)
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run() didn't return after cancellation")
+	}
+
+	for _, field := range strings.Fields(string(out)) {
+		pid, err := strconv.Atoi(field)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for deadline := time.Now().Add(5 * time.Second); alive(pid); {
+			if time.Now().After(deadline) {
+				t.Errorf("process %d still running after cancellation", pid)
+				syscall.Kill(pid, syscall.SIGKILL)
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func TestWithNiceness(t *testing.T) {
+	path := fakeExecutable(t, `ps -o ni= -p $$`)
+
+	for _, n := range []int{0, 5, 19} {
+		cmd, err := NewCommand(WithCustomPath(path), WithNiceness(n))
+		if err != nil {
+			t.Fatalf("NewCommand() error = %v", err)
+		}
+
+		out, err := cmd.RunString(context.Background(), "in.pdf")
+		if err != nil {
+			t.Fatalf("RunString() error = %v", err)
+		}
+
+		// relative to the niceness of the test
+		got, err := strconv.Atoi(strings.TrimSpace(out))
+		if err != nil {
+			t.Fatalf("niceness %q: %v", out, err)
+		}
+		if want := min(niceness(t)+n, 19); got != want {
+			t.Errorf("WithNiceness(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+// niceness returns the niceness of the test process.
+func niceness(t *testing.T) int {
+	t.Helper()
+
+	prio, err := syscall.Getpriority(syscall.PRIO_PROCESS, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the raw value on Linux is 20 - niceness
+	if runtime.GOOS == "linux" {
+		return 20 - prio
+	}
+
+	return prio
+}
+
+func TestRunStreamCancelWithoutReading(t *testing.T) {
+	for _, name := range []string{"cancel", "close"} {
+		t.Run(name, func(t *testing.T) {
+			pidfile := filepath.Join(t.TempDir(), "pid")
+
+			// a large conversion, blocked on the full pipe as nothing is read
+			path := fakeExecutable(t, `echo $$ > `+pidfile+`.tmp && mv `+pidfile+`.tmp `+pidfile+`
+while :; do echo "a line of text of a large document"; done
+`)
+
+			cmd, err := NewCommand(WithCustomPath(path))
+			if err != nil {
+				t.Fatalf("NewCommand() error = %v", err)
+			}
+
+			goroutines := runtime.NumGoroutine()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			r, err := cmd.RunStream(ctx, "in.pdf")
+			if err != nil {
+				t.Fatalf("RunStream() error = %v", err)
+			}
+
+			var out []byte
+			for deadline := time.Now().Add(10 * time.Second); len(out) == 0; {
+				if time.Now().After(deadline) {
+					t.Fatal("the process didn't start")
+				}
+				time.Sleep(10 * time.Millisecond)
+				out, _ = os.ReadFile(pidfile)
+			}
+			pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// let the pipe fill up
+			time.Sleep(50 * time.Millisecond)
+			if name == "cancel" {
+				cancel()
+			}
+
+			closed := make(chan error, 1)
+			go func() { closed <- r.Close() }()
+
+			select {
+			case <-closed:
+			case <-time.After(10 * time.Second):
+				syscall.Kill(pid, syscall.SIGKILL)
+				t.Fatal("Close() didn't return")
+			}
+
+			if alive(pid) {
+				t.Errorf("process %d still running after Close()", pid)
+				syscall.Kill(pid, syscall.SIGKILL)
+			}
+
+			// no goroutine is left copying the output
+			for deadline := time.Now().Add(5 * time.Second); runtime.NumGoroutine() > goroutines; {
+				if time.Now().After(deadline) {
+					t.Errorf("goroutines = %d, want %d", runtime.NumGoroutine(), goroutines)
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package pdftotext
+
+import (
+	"context"
+	"errors"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` raw
+// ----------------------------------------------------------------------------
+
+// RunRaw executes prepared `pdftotext` command and returns its standard output
+// and error verbatim, along with the exit code.
+//
+// No transforms are applied and a non-zero exit code is not an error; `err`
+// is only returned if `pdftotext` couldn't be started or was killed, e.g. on
+// cancellation. It's the lowest-level entry point, useful for troubleshooting;
+// the other helpers build on the same execution path.
+func (c *Command) RunRaw(ctx context.Context, inpath string) (stdout []byte, stderr []byte, exitCode int, err error) {
+	inpath, err = c.inpath(inpath)
+	if err != nil {
+		return nil, nil, -1, err
+	}
+
+	stdout, stderr, err = c.exec(ctx, inpath, nil)
+
+	var ce *CommandError
+	if errors.As(err, &ce) && ce.ExitCode >= 0 {
+		return stdout, stderr, ce.ExitCode, nil
+	}
+	if err != nil {
+		return stdout, stderr, -1, err
+	}
+
+	return stdout, stderr, 0, nil
+}
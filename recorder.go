@@ -0,0 +1,167 @@
+package pdftotext
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` recorder
+// ----------------------------------------------------------------------------
+
+// recordLimit is the number of output bytes kept in a recording.
+const recordLimit = 4 * 1024
+
+// Recording describes a single conversion by `pdftotext`.
+type Recording struct {
+	Args      []string // passwords are redacted
+	Input     string
+	InputSize int64 // -1 if unknown
+	Start     time.Time
+	Duration  time.Duration
+	ExitCode  int    // -1 if the process didn't exit
+	Stdout    []byte // truncated to 4 KiB
+	Stderr    []byte // truncated to 4 KiB
+	Err       error
+}
+
+// Recorder receives a recording of each conversion by `pdftotext`.
+//
+// Record may be called from multiple goroutines at once.
+type Recorder interface {
+	Record(rec Recording)
+}
+
+// MemoryRecorder is a Recorder keeping recordings in memory, e.g. for tests.
+type MemoryRecorder struct {
+	mu   sync.Mutex
+	recs []Recording
+}
+
+// Record appends `rec` to the recordings.
+func (r *MemoryRecorder) Record(rec Recording) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recs = append(r.recs, rec)
+}
+
+// Recordings returns all recordings so far, in order.
+func (r *MemoryRecorder) Recordings() []Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Recording(nil), r.recs...)
+}
+
+// recording collects a recording while `pdftotext` is executed.
+type recording struct {
+	rec    Recording
+	stdin  *countReader
+	stdout limitBuffer
+	stderr limitBuffer
+}
+
+func (c *Command) startRecording(cmd *exec.Cmd, inpath string) *recording {
+	if c.recorder == nil && c.observer == nil && c.logger == nil {
+		return nil
+	}
+
+	r := &recording{rec: Recording{
+		Args:      append([]string{c.path}, c.redacted(inpath)...),
+		Input:     inpath,
+		InputSize: -1,
+		Start:     time.Now(),
+		ExitCode:  -1,
+	}}
+
+	if f, ok := cmd.Stdin.(*os.File); ok {
+		// keep passing the file to the process directly
+		if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() {
+			r.rec.InputSize = fi.Size()
+		}
+	} else if cmd.Stdin != nil {
+		r.stdin = &countReader{r: cmd.Stdin}
+		cmd.Stdin = r.stdin
+	} else if fi, err := os.Stat(c.resolve(inpath)); err == nil {
+		r.rec.InputSize = fi.Size()
+	}
+
+	if cmd.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, &r.stderr)
+	} else {
+		cmd.Stderr = &r.stderr
+	}
+
+	return r
+}
+
+func (c *Command) stopRecording(ctx context.Context, r *recording, cmd *exec.Cmd, err error) {
+	if r == nil {
+		return
+	}
+
+	r.rec.Duration = time.Since(r.rec.Start)
+	if r.stdin != nil {
+		r.rec.InputSize = r.stdin.n
+	}
+	if cmd.ProcessState != nil {
+		r.rec.ExitCode = cmd.ProcessState.ExitCode()
+	} else if code, ok := exitCode(err); ok {
+		r.rec.ExitCode = code
+	} else if err == nil {
+		r.rec.ExitCode = 0
+	}
+	r.rec.Stdout = r.stdout.buf
+	r.rec.Stderr = r.stderr.buf
+	r.rec.Err = err
+
+	if c.recorder != nil {
+		c.recorder.Record(r.rec)
+	}
+	if c.logger != nil {
+		c.log(ctx, r.rec)
+	}
+	if c.observer != nil {
+		c.observe(ExecInfo{
+			Args:     r.rec.Args,
+			Start:    r.rec.Start,
+			End:      r.rec.Start.Add(r.rec.Duration),
+			ExitCode: r.rec.ExitCode,
+			Bytes:    r.stdout.n,
+			Err:      err,
+		})
+	}
+}
+
+// countReader counts bytes read from the wrapped reader.
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// limitBuffer keeps the first `recordLimit` bytes written to it, and counts
+// all of them.
+type limitBuffer struct {
+	buf []byte
+	n   int64
+}
+
+func (b *limitBuffer) Write(p []byte) (int, error) {
+	b.n += int64(len(p))
+	if n := recordLimit - len(b.buf); n > 0 {
+		b.buf = append(b.buf, p[:min(n, len(p))]...)
+	}
+
+	return len(p), nil
+}
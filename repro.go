@@ -0,0 +1,51 @@
+package pdftotext
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` reproducibility
+// ----------------------------------------------------------------------------
+
+// ReproInfo is a sanitized description of the command and its environment,
+// suitable for attaching to bug reports.
+type ReproInfo struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Version string   `json:"version"` // as returned by `Version`
+	Banner  string   `json:"banner"`  // first line of `pdftotext -v`
+	OS      string   `json:"os"`
+	Arch    string   `json:"arch"`
+}
+
+// ReproInfo gathers a description of the command for reproducing issues.
+//
+// Passwords are replaced with "***" and the config-file path is reduced to
+// its base name. If the version can't be parsed from the banner, it's empty,
+// and the banner is still included.
+func (c *Command) ReproInfo(ctx context.Context) (ReproInfo, error) {
+	out, err := c.banner(ctx)
+	if err != nil {
+		return ReproInfo{}, err
+	}
+
+	version, _ := parseVersion(out)
+
+	args := c.redacted("<inpath>")
+	if c.config > 0 {
+		args[c.config] = filepath.Base(args[c.config])
+	}
+
+	return ReproInfo{
+		Command: exec.Command(c.path, args...).String(),
+		Args:    args[:len(c.args)],
+		Version: version,
+		Banner:  firstLine(out),
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package pdftotext
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` result
+// ----------------------------------------------------------------------------
+
+// Result is the result of converting a single PDF file.
+type Result struct {
+	Text     []byte
+	Pages    int // number of page breaks in the output
+	Duration time.Duration
+	Command  string // converting the file, with passwords redacted
+
+	// Warnings are diagnostics of a successful run, e.g. "Syntax Warning:
+	// ...", one per line of the standard error of `pdftotext`.
+	Warnings []string
+
+	// FontWarnings are the warnings about fonts, e.g. of a non-embedded font
+	// that couldn't be found, so the extracted characters may be wrong. Use
+	// `WithStrictFonts` to fail on them instead.
+	FontWarnings []string
+
+	// Partial is the output produced before `pdftotext` failed, e.g. pages
+	// before a malformed object. It's set only along with an error, and no
+	// transforms are applied to it.
+	Partial []byte
+
+	// Err is the error of the conversion, set only by `RunBatch`.
+	Err error
+}
+
+// RunResult executes prepared `pdftotext` command and returns the output along
+// with metadata, e.g. for metrics.
+//
+// If the conversion fails, the result is returned along with the error, with
+// the output produced so far in `Partial`.
+func (c *Command) RunResult(ctx context.Context, inpath string) (*Result, error) {
+	inpath, err := c.inpath(inpath)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	out, warns, err := c.output(ctx, inpath, nil)
+
+	res := &Result{
+		Duration: time.Since(start),
+		Command:  c.command(inpath),
+	}
+
+	if failed(err) {
+		res.Partial = out
+		return res, err
+	}
+
+	txt, terr := c.transformBytes(out)
+	if terr != nil {
+		return nil, terr
+	}
+
+	res.Text = txt
+	res.Warnings = warns
+	res.FontWarnings = fontWarnings(warns)
+	res.Pages = bytes.Count(txt, []byte(pageBreak))
+
+	return res, err
+}
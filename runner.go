@@ -0,0 +1,46 @@
+package pdftotext
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` runner
+// ----------------------------------------------------------------------------
+
+// Runner executes prepared `pdftotext` process.
+//
+// The process is fully set up, including standard streams, so a fake Runner
+// can check `cmd.Args`, write canned output to `cmd.Stdout` and `cmd.Stderr`,
+// and return an error with an `ExitCode() int` method to simulate a non-zero
+// exit code. `ctx` is the context `cmd` was created with.
+type Runner interface {
+	Run(ctx context.Context, cmd *exec.Cmd) error
+}
+
+// RunnerFunc is a function implementing Runner.
+type RunnerFunc func(ctx context.Context, cmd *exec.Cmd) error
+
+// Run calls f(ctx, cmd).
+func (f RunnerFunc) Run(ctx context.Context, cmd *exec.Cmd) error {
+	return f(ctx, cmd)
+}
+
+// execRunner is the default Runner, starting a real process.
+type execRunner struct{}
+
+func (execRunner) Run(_ context.Context, cmd *exec.Cmd) error {
+	return cmd.Run()
+}
+
+// exitCode returns the exit code reported by `err`, if any.
+func exitCode(err error) (int, bool) {
+	var ec interface{ ExitCode() int }
+	if errors.As(err, &ec) {
+		return ec.ExitCode(), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,443 @@
+package pdftotext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// popplerBanner is the output of `pdftotext -v` of Poppler.
+const popplerBanner = "pdftotext version 22.02.0\nCopyright 2005-2022 The Poppler Developers - http://poppler.freedesktop.org\nCopyright 1996-2011 Glyph & Cog, LLC\n"
+
+// xpdfBanner is the output of `pdftotext -v` of Xpdf.
+const xpdfBanner = "pdftotext version 4.05 [www.xpdfreader.com]\nCopyright 1996-2024 Glyph & Cog, LLC\n"
+
+// fakeResult is the result of a fake `pdftotext` process.
+type fakeResult struct {
+	stdout string
+	stderr string
+	code   int
+}
+
+// fakeRunner is a Runner recording the arguments of each process, and
+// answering it with `respond`. `pdftotext -v` is answered with `banner`,
+// Poppler's by default.
+type fakeRunner struct {
+	banner  string
+	respond func(args []string) fakeResult
+
+	mu    sync.Mutex
+	calls [][]string
+	dirs  []string   // directory of each process
+	envs  [][]string // environment of each process
+}
+
+func (f *fakeRunner) Run(ctx context.Context, cmd *exec.Cmd) error {
+	args := cmd.Args[1:]
+
+	f.mu.Lock()
+	f.calls = append(f.calls, args)
+	f.dirs = append(f.dirs, cmd.Dir)
+	f.envs = append(f.envs, cmd.Env)
+	f.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var res fakeResult
+	switch {
+	case slices.Equal(args, []string{"-v"}):
+		res.stderr = popplerBanner
+		if f.banner != "" {
+			res.stderr = f.banner
+		}
+	case f.respond != nil:
+		res = f.respond(args)
+	}
+
+	if _, err := io.WriteString(cmd.Stdout, res.stdout); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(cmd.Stderr, res.stderr); err != nil {
+		return err
+	}
+	if res.code != 0 {
+		return exitStatus(res.code)
+	}
+
+	return nil
+}
+
+// conversions returns the arguments of each process, except `-v`.
+func (f *fakeRunner) conversions() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls [][]string
+	for _, args := range f.calls {
+		if !slices.Equal(args, []string{"-v"}) {
+			calls = append(calls, args)
+		}
+	}
+
+	return calls
+}
+
+// last returns the arguments and directory of the last process.
+func (f *fakeRunner) last() ([]string, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.calls) == 0 {
+		return nil, ""
+	}
+
+	return f.calls[len(f.calls)-1], f.dirs[len(f.dirs)-1]
+}
+
+// exitStatus is an error of a process exiting with a non-zero code.
+type exitStatus int
+
+func (e exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+func (e exitStatus) ExitCode() int {
+	return int(e)
+}
+
+// output returns a `respond` function always answering with `stdout`.
+func output(stdout string) func(args []string) fakeResult {
+	return func([]string) fakeResult {
+		return fakeResult{stdout: stdout}
+	}
+}
+
+// newFake creates a command run by `f`.
+func newFake(t *testing.T, f *fakeRunner, opts ...option) *Command {
+	t.Helper()
+
+	cmd, err := NewCommand(append([]option{WithRunner(f)}, opts...)...)
+	if err != nil {
+		t.Fatalf("NewCommand() error = %v", err)
+	}
+
+	return cmd
+}
+
+func TestWithRunner(t *testing.T) {
+	f := &fakeRunner{respond: output("page one\f")}
+
+	// the executable is never searched for
+	cmd := newFake(t, f, WithCustomPath("/nonexistent/pdftotext"), WithModeLayout())
+
+	out, err := cmd.RunString(context.Background(), "in.pdf")
+	if err != nil {
+		t.Fatalf("RunString() error = %v", err)
+	}
+	if out != "page one\f" {
+		t.Errorf("RunString() = %q, want %q", out, "page one\f")
+	}
+
+	want := [][]string{{"-layout", "in.pdf", "-"}}
+	if got := f.conversions(); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
+
+func TestWithRunnerDefaultPath(t *testing.T) {
+	cmd := newFake(t, &fakeRunner{})
+
+	if got := cmd.Args("in.pdf")[0]; got != "pdftotext" {
+		t.Errorf("path = %q, want %q", got, "pdftotext")
+	}
+}
+
+func TestRunResultPartial(t *testing.T) {
+	f := &fakeRunner{respond: func([]string) fakeResult {
+		return fakeResult{stdout: "page one\f", stderr: "Syntax Error: bad object", code: 1}
+	}}
+	cmd := newFake(t, f)
+
+	res, err := cmd.RunResult(context.Background(), "in.pdf")
+
+	var ce *CommandError
+	if !errors.As(err, &ce) {
+		t.Fatalf("RunResult() error = %v, want *CommandError", err)
+	}
+	if ce.ExitCode != 1 || ce.Stderr != "Syntax Error: bad object" {
+		t.Errorf("CommandError = %d, %q, want 1, %q", ce.ExitCode, ce.Stderr, "Syntax Error: bad object")
+	}
+	if res == nil || string(res.Partial) != "page one\f" {
+		t.Fatalf("RunResult() partial = %+v, want %q", res, "page one\f")
+	}
+	if res.Text != nil {
+		t.Errorf("RunResult() text = %q, want nil", res.Text)
+	}
+}
+
+func TestRunWithPasswordRetry(t *testing.T) {
+	f := &fakeRunner{respond: func(args []string) fakeResult {
+		if !slices.Contains(args, "-upw") {
+			return fakeResult{stderr: "Command Line Error: Incorrect password", code: 1}
+		}
+		return fakeResult{stdout: "secret text\f"}
+	}}
+	cmd := newFake(t, f)
+
+	calls := 0
+	out, err := cmd.RunWithPasswordRetry(context.Background(), "in.pdf", func(context.Context) (string, error) {
+		calls++
+		return "s3cret", nil
+	})
+	if err != nil {
+		t.Fatalf("RunWithPasswordRetry() error = %v", err)
+	}
+	if b, _ := io.ReadAll(out); string(b) != "secret text\f" {
+		t.Errorf("RunWithPasswordRetry() = %q, want %q", b, "secret text\f")
+	}
+	if calls != 1 {
+		t.Errorf("password callback called %d times, want 1", calls)
+	}
+
+	got := f.conversions()
+	if len(got) != 2 {
+		t.Fatalf("runs = %q, want 2", got)
+	}
+	want := []string{"-upw", "s3cret", "-opw", "s3cret", "in.pdf", "-"}
+	if !slices.Equal(got[1], want) {
+		t.Errorf("retry args = %q, want %q", got[1], want)
+	}
+}
+
+func TestRunWithPasswordRetryNotEncrypted(t *testing.T) {
+	cmd := newFake(t, &fakeRunner{respond: output("text\f")})
+
+	_, err := cmd.RunWithPasswordRetry(context.Background(), "in.pdf", func(context.Context) (string, error) {
+		t.Error("password callback called for a file that isn't encrypted")
+		return "", nil
+	})
+	if err != nil {
+		t.Fatalf("RunWithPasswordRetry() error = %v", err)
+	}
+}
+
+func TestExitCodeErrors(t *testing.T) {
+	tests := []struct {
+		code int
+		want error
+	}{
+		{1, ErrOpenPDF},
+		{2, ErrOpenOutput},
+		{3, ErrPermission},
+		{99, ErrOther},
+	}
+
+	sentinels := []error{ErrOpenPDF, ErrOpenOutput, ErrPermission, ErrOther}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.code), func(t *testing.T) {
+			cmd := newFake(t, &fakeRunner{respond: func([]string) fakeResult {
+				return fakeResult{stderr: "failed", code: tt.code}
+			}})
+
+			_, err := cmd.Run(context.Background(), "in.pdf")
+
+			for _, s := range sentinels {
+				if got := errors.Is(err, s); got != (s == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %t", err, s, got)
+				}
+			}
+
+			var ce *CommandError
+			if !errors.As(err, &ce) || ce.ExitCode != tt.code || ce.Stderr != "failed" {
+				t.Errorf("error = %#v, want *CommandError with code %d", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	f := &fakeRunner{respond: func([]string) fakeResult {
+		return fakeResult{stdout: "text\f", stderr: "Syntax Warning: bad xref\n\nSyntax Error: missing font\n"}
+	}}
+
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	cmd := newFake(t, f, WithOnWarning(func(w string) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, w)
+	}))
+
+	res, err := cmd.RunResult(context.Background(), "in.pdf")
+	if err != nil {
+		t.Fatalf("RunResult() error = %v", err)
+	}
+
+	want := []string{"Syntax Warning: bad xref", "Syntax Error: missing font"}
+	if !slices.Equal(res.Warnings, want) {
+		t.Errorf("Result.Warnings = %q, want %q", res.Warnings, want)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("OnWarning got %q, want %q", got, want)
+	}
+
+	// streamed conversions report warnings too
+	got = nil
+	if err := cmd.RunTo(context.Background(), "in.pdf", io.Discard); err != nil {
+		t.Fatalf("RunTo() error = %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("OnWarning got %q, want %q", got, want)
+	}
+}
+
+func TestWarningsNotOnFailure(t *testing.T) {
+	f := &fakeRunner{respond: func([]string) fakeResult {
+		return fakeResult{stderr: "Syntax Error: broken", code: 1}
+	}}
+	cmd := newFake(t, f, WithOnWarning(func(w string) {
+		t.Errorf("OnWarning(%q) called for a failed run", w)
+	}))
+
+	if _, err := cmd.Run(context.Background(), "in.pdf"); err == nil {
+		t.Fatal("Run() error = nil, want failure")
+	}
+}
+
+func TestRunnerCancellation(t *testing.T) {
+	cmd := newFake(t, &fakeRunner{respond: output("text")})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := cmd.Run(ctx, "in.pdf")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	if !strings.Contains(err.Error(), "pdftotext in.pdf -") {
+		t.Errorf("Run() error = %v, want the command", err)
+	}
+}
+
+func TestFontWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		banner string
+		stderr string
+		want   []string
+	}{
+		{
+			"Poppler", popplerBanner,
+			"Syntax Error: Unknown font tag 'F3'\nSyntax Warning: bad xref\nSyntax Error: Couldn't create a font for 'Arial'\n",
+			[]string{"Syntax Error: Unknown font tag 'F3'", "Syntax Error: Couldn't create a font for 'Arial'"},
+		},
+		{
+			"Xpdf", xpdfBanner,
+			"Config Error: No display font for 'Symbol'\nSyntax Error: Couldn't find a font for 'Helvetica'\n",
+			[]string{"Config Error: No display font for 'Symbol'", "Syntax Error: Couldn't find a font for 'Helvetica'"},
+		},
+		{"none", popplerBanner, "Syntax Warning: bad xref\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{banner: tt.banner, respond: func([]string) fakeResult {
+				return fakeResult{stdout: "text\f", stderr: tt.stderr}
+			}}
+			cmd := newFake(t, f)
+
+			res, err := cmd.RunResult(context.Background(), "in.pdf")
+			if err != nil {
+				t.Fatalf("RunResult() error = %v", err)
+			}
+			if !slices.Equal(res.FontWarnings, tt.want) {
+				t.Errorf("Result.FontWarnings = %q, want %q", res.FontWarnings, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithStrictFonts(t *testing.T) {
+	runs := map[string]func(cmd *Command) error{
+		"Run": func(cmd *Command) error {
+			_, err := cmd.Run(context.Background(), "in.pdf")
+			return err
+		},
+		"RunTo": func(cmd *Command) error {
+			return cmd.RunTo(context.Background(), "in.pdf", io.Discard)
+		},
+		"RunStream": func(cmd *Command) error {
+			r, err := cmd.RunStream(context.Background(), "in.pdf")
+			if err != nil {
+				return err
+			}
+			if _, err := io.ReadAll(r); err != nil {
+				r.Close()
+				return err
+			}
+			return r.Close()
+		},
+	}
+
+	for name, run := range runs {
+		t.Run(name, func(t *testing.T) {
+			stderr := "Syntax Warning: bad xref\nSyntax Error: Couldn't find a font for 'Helvetica'\n"
+			cmd := newFake(t, &fakeRunner{respond: func([]string) fakeResult {
+				return fakeResult{stdout: "text\f", stderr: stderr}
+			}}, WithStrictFonts())
+
+			err := run(cmd)
+			if !errors.Is(err, ErrFontWarnings) || !strings.Contains(err.Error(), "Helvetica") {
+				t.Errorf("%s() error = %v, want ErrFontWarnings naming the font", name, err)
+			}
+
+			// other warnings don't fail
+			cmd = newFake(t, &fakeRunner{respond: func([]string) fakeResult {
+				return fakeResult{stdout: "text\f", stderr: "Syntax Warning: bad xref\n"}
+			}}, WithStrictFonts())
+			if err := run(cmd); err != nil {
+				t.Errorf("%s() error = %v, want nil", name, err)
+			}
+		})
+	}
+}
+
+func TestErrEncrypted(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		code   int
+		want   error
+	}{
+		{"incorrect password", "Command Line Error: Incorrect password", 1, ErrEncrypted},
+		{"damaged file", "Syntax Error: Couldn't find trailer dictionary", 1, ErrOpenPDF},
+		{"copying not allowed", "Permission Error: Copying of text from this document is not allowed.", 3, ErrPermission},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newFake(t, &fakeRunner{respond: func([]string) fakeResult {
+				return fakeResult{stderr: tt.stderr, code: tt.code}
+			}})
+
+			_, err := cmd.Run(context.Background(), "in.pdf")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Run() error = %v, want %v", err, tt.want)
+			}
+			if got := errors.Is(err, ErrEncrypted); got != (tt.want == ErrEncrypted) {
+				t.Errorf("errors.Is(%v, ErrEncrypted) = %t", err, got)
+			}
+		})
+	}
+}
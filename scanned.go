@@ -0,0 +1,50 @@
+package pdftotext
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` scanned documents
+// ----------------------------------------------------------------------------
+
+// defaultScannedThreshold is the number of non-whitespace characters per page
+// below which a document is likely scanned, unless set with
+// `WithScannedThreshold`.
+const defaultScannedThreshold = 20
+
+// RunDetectScanned executes prepared `pdftotext` command and returns the output
+// as a string, along with whether the document is likely scanned, i.e. made
+// of images with little or no text to extract, e.g. to fall back to OCR.
+//
+// A document is likely scanned if it has fewer non-whitespace characters per
+// page, on average, than the threshold set with `WithScannedThreshold`. It's
+// a heuristic, not a guarantee: e.g. a scan with an OCR text layer is not
+// detected, and a document of nearly empty pages is.
+//
+// With `ErrTooManyPages`, the pages allowed by `WithMaxPagesFast` are returned
+// and checked, along with the error.
+func (c *Command) RunDetectScanned(ctx context.Context, inpath string) (string, bool, error) {
+	text, err := c.RunString(ctx, inpath)
+	if failed(err) {
+		return "", false, err
+	}
+
+	pages := max(strings.Count(text, pageBreak), 1)
+
+	chars := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			chars++
+		}
+	}
+
+	threshold := c.scannedThreshold
+	if threshold == 0 {
+		threshold = defaultScannedThreshold
+	}
+
+	return text, chars < threshold*pages, err
+}
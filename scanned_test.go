@@ -0,0 +1,46 @@
+package pdftotext
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunDetectScanned(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   bool
+	}{
+		{"text", strings.Repeat("word ", 10) + "\f", false},
+		{"images", " \n\f\f", true},
+		{"sparse pages", "page one\f\f\f", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newFake(t, &fakeRunner{respond: output(tt.output)}, WithScannedThreshold(5))
+
+			text, scanned, err := cmd.RunDetectScanned(context.Background(), "in.pdf")
+			if err != nil {
+				t.Fatalf("RunDetectScanned() error = %v", err)
+			}
+			if text != tt.output || scanned != tt.want {
+				t.Errorf("RunDetectScanned() = %q, %t, want %q, %t", text, scanned, tt.output, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunDetectScannedTooManyPages(t *testing.T) {
+	cmd := newFake(t, pagesRunner(5), WithMaxPagesFast(2), WithScannedThreshold(1))
+
+	text, scanned, err := cmd.RunDetectScanned(context.Background(), "in.pdf")
+	if !errors.Is(err, ErrTooManyPages) {
+		t.Fatalf("RunDetectScanned() error = %v, want ErrTooManyPages", err)
+	}
+	if text != "1\n\f2\n\f" || scanned {
+		t.Errorf("RunDetectScanned() = %q, %t, want %q, false", text, scanned, "1\n\f2\n\f")
+	}
+}
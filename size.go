@@ -0,0 +1,67 @@
+package pdftotext
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` page size
+// ----------------------------------------------------------------------------
+
+var bboxPage = regexp.MustCompile(`<page width="([0-9.]+)" height="([0-9.]+)">`)
+
+// ExtractPagesBySize executes prepared `pdftotext` command and returns only
+// pages whose dimensions, in points, satisfy `pred`.
+//
+// Dimensions aren't part of the text output, so they are probed with an extra
+// `-bbox` run first. The `-bbox` option is only supported by Poppler, so it
+// fails for Xpdf.
+func (c *Command) ExtractPagesBySize(ctx context.Context, inpath string, pred func(w, h float64) bool) ([]Page, error) {
+	if err := c.require(ctx, requirement{"-bbox", VariantPoppler}); err != nil {
+		return nil, err
+	}
+
+	probe := c.withArgs("-bbox")
+	probe.transforms, probe.transcode, probe.stripBOM, probe.maxPages = nil, false, false, 0
+
+	out, err := probe.Run(ctx, inpath)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := io.ReadAll(out)
+	if err != nil {
+		return nil, err
+	}
+
+	match := make(map[int]bool)
+	for i, m := range bboxPage.FindAllSubmatch(html, -1) {
+		w, _ := strconv.ParseFloat(string(m[1]), 64)
+		h, _ := strconv.ParseFloat(string(m[2]), 64)
+		if pred(w, h) {
+			match[c.firstPage()+i] = true
+		}
+	}
+
+	if len(match) == 0 {
+		return nil, nil
+	}
+
+	// pages may be skipped by `WithSkipLeadingPages`, so match them by number
+	all, err := c.paged(ctx, inpath)
+	if failed(err) {
+		return nil, err
+	}
+
+	pages := make([]Page, 0, len(match))
+	for _, page := range all {
+		if match[page.Number] {
+			pages = append(pages, page)
+		}
+	}
+
+	return pages, err
+}
@@ -0,0 +1,67 @@
+package pdftotext
+
+import (
+	"context"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` stats
+// ----------------------------------------------------------------------------
+
+// Stats executes prepared `pdftotext` command once and counts words, characters
+// and pages of the output, e.g. for billing.
+//
+// Words are separated by whitespace, except in scripts written without it,
+// i.e. Chinese and Japanese, where each character counts as a word.
+// Characters don't include whitespace. Pages are counted by page breaks, so
+// it's 0 with `WithNoPageBreak`.
+//
+// With `ErrTooManyPages`, the pages allowed by `WithMaxPagesFast` are counted
+// and returned along with the error.
+func (c *Command) Stats(ctx context.Context, inpath string) (words, chars, pages int, err error) {
+	out, err := c.RunBytes(ctx, inpath)
+	if failed(err) {
+		return 0, 0, 0, err
+	}
+
+	words, chars, pages = countText(out)
+
+	return words, chars, pages, err
+}
+
+// countText counts words, characters and pages of `text`, as in `Stats`.
+func countText(text []byte) (words, chars, pages int) {
+	inWord := false
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		text = text[size:]
+
+		switch {
+		case r == rune(pageBreak[0]):
+			pages++
+			inWord = false
+		case unicode.IsSpace(r):
+			inWord = false
+		case isIdeographic(r):
+			words++
+			chars++
+			inWord = false
+		default:
+			if !inWord {
+				words++
+			}
+			chars++
+			inWord = true
+		}
+	}
+
+	return words, chars, pages
+}
+
+// isIdeographic reports whether `r` is of a script written without spaces
+// between words.
+func isIdeographic(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
@@ -0,0 +1,31 @@
+package pdftotext
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	cmd := newFake(t, &fakeRunner{respond: output("one two\fthree 日本\f")})
+
+	words, chars, pages, err := cmd.Stats(context.Background(), "in.pdf")
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if words != 5 || chars != 13 || pages != 2 {
+		t.Errorf("Stats() = %d, %d, %d, want 5, 13, 2", words, chars, pages)
+	}
+}
+
+func TestStatsTooManyPages(t *testing.T) {
+	cmd := newFake(t, pagesRunner(5), WithMaxPagesFast(2))
+
+	words, chars, pages, err := cmd.Stats(context.Background(), "in.pdf")
+	if !errors.Is(err, ErrTooManyPages) {
+		t.Fatalf("Stats() error = %v, want ErrTooManyPages", err)
+	}
+	if words != 2 || chars != 2 || pages != 2 {
+		t.Errorf("Stats() = %d, %d, %d, want 2, 2, 2", words, chars, pages)
+	}
+}
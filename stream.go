@@ -0,0 +1,284 @@
+package pdftotext
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` streaming
+// ----------------------------------------------------------------------------
+
+// streamChunkSize is the size of the chunks passed to the stream callback.
+const streamChunkSize = 32 * 1024
+
+// RunFunc executes prepared `pdftotext` command and passes the output to `fn`
+// in chunks as they arrive, without aggregating it.
+//
+// The chunk is only valid until `fn` returns. The output is not read while
+// `fn` is running, so a slow `fn` blocks `pdftotext` as well. If `fn` returns
+// an error, the command is killed and the error is returned. With
+// `WithMaxPagesFast`, the command is killed after the last allowed page, and
+// `ErrTooManyPages` is returned once it has been passed to `fn`.
+func (c *Command) RunFunc(ctx context.Context, inpath string, fn func(chunk []byte) error) error {
+	inpath, err := c.inpath(inpath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.timeout(ctx)
+	defer cancel()
+
+	args, cleanup, err := c.prepare(ctx, inpath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	pr, pw := io.Pipe()
+
+	var stderr []byte
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		stderr, err = c.execArgs(ctx, inpath, args, nil, pw)
+		pw.Close()
+		done <- err
+	}()
+
+	err = stream(c.buffering.reader(c.transform(c.limit(pr))), fn)
+	if err != nil {
+		// unblock the process writing to the pipe, so it can be killed
+		cancel()
+		pr.CloseWithError(err)
+		<-done
+
+		return err
+	}
+
+	if err = <-done; err == nil {
+		err = c.strictFonts(c.warn(stderr))
+	}
+
+	return err
+}
+
+// RunTo executes prepared `pdftotext` command and writes the output to `w` as
+// it is produced, so the output is never held in memory as a whole.
+//
+// If writing to `w` fails, the command is killed and the error is returned.
+func (c *Command) RunTo(ctx context.Context, inpath string, w io.Writer) error {
+	return c.RunFunc(ctx, inpath, func(chunk []byte) error {
+		_, err := w.Write(chunk)
+		return err
+	})
+}
+
+// RunStream executes prepared `pdftotext` command and returns its output as
+// a reader, streamed directly from the process as it's read, e.g. to pass it
+// on with `io.Copy`.
+//
+// A non-zero exit is returned by the final `Read`, in place of `io.EOF`, and
+// again by `Close`, as is `ErrTooManyPages` after the last page allowed by
+// `WithMaxPagesFast`. The reader must be closed: closing it before reading to
+// the end kills the process, and no error is returned for it.
+func (c *Command) RunStream(ctx context.Context, inpath string) (io.ReadCloser, error) {
+	inpath, err := c.inpath(inpath)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.timeout(ctx)
+
+	args, cleanup, err := c.prepare(ctx, inpath)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		stderr, err := c.execArgs(ctx, inpath, args, nil, pw)
+		if err == nil {
+			err = c.strictFonts(c.warn(stderr))
+		}
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	return &streamReader{
+		r:      c.transform(c.limit(pr)),
+		pr:     pr,
+		cancel: cancel,
+		wait: sync.OnceValue(func() error {
+			err := <-done
+			cleanup()
+			cancel()
+
+			return err
+		}),
+	}, nil
+}
+
+// errStreamClosed is returned to the process writing to a closed stream.
+var errStreamClosed = errors.New("pdftotext: stream closed")
+
+// streamReader is the output of `RunStream`.
+type streamReader struct {
+	r      io.Reader
+	pr     *io.PipeReader
+	cancel context.CancelFunc
+	wait   func() error // waits for the process to finish, once
+	end    bool         // read to the end
+	limit  bool         // stopped after the last allowed page
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err != nil {
+		s.end = true
+		s.limited(err)
+	}
+
+	return n, err
+}
+
+// WriteTo writes the output to `w` as it's produced, e.g. for `io.Copy`, with
+// the output of transforms passed on without an intermediate buffer.
+func (s *streamReader) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.Copy(w, s.r)
+	// on error, e.g. of `w`, the process may still be writing
+	s.end = err == nil
+	s.limited(err)
+
+	return n, err
+}
+
+// limited kills the process still converting pages after the last allowed one.
+func (s *streamReader) limited(err error) {
+	if err == ErrTooManyPages {
+		s.stop()
+		s.end, s.limit = true, true
+	}
+}
+
+// stop kills the process and waits for it to finish.
+func (s *streamReader) stop() {
+	// unblock the process writing to the pipe, so it can be killed
+	s.cancel()
+	s.pr.CloseWithError(errStreamClosed)
+	s.wait()
+}
+
+// Close kills the process, unless the output was read to the end, and waits
+// for it to finish.
+func (s *streamReader) Close() error {
+	if !s.end {
+		s.stop()
+		return nil
+	}
+	if s.limit {
+		return ErrTooManyPages
+	}
+
+	return s.wait()
+}
+
+// limit returns `r` failing with `ErrTooManyPages` after the last page allowed
+// by `WithMaxPagesFast`, if there's any output past it.
+func (c *Command) limit(r io.Reader) io.Reader {
+	if c.maxPages == 0 {
+		return r
+	}
+
+	return &pageLimitReader{r: r, left: c.maxPages}
+}
+
+// pageLimitReader passes on the output of the wrapped reader up to the last
+// allowed page break, see `Command.limit`.
+type pageLimitReader struct {
+	r    io.Reader
+	left uint64 // page breaks left to pass on
+	err  error
+}
+
+func (l *pageLimitReader) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+
+	n, err := l.r.Read(p)
+
+	for i := 0; i < n; i++ {
+		if l.left == 0 {
+			// any output past the last allowed page is of the next page
+			l.err = ErrTooManyPages
+			if i == 0 {
+				return 0, l.err
+			}
+			return i, nil
+		}
+		if p[i] == pageBreak[0] {
+			l.left--
+		}
+	}
+
+	return n, err
+}
+
+// stream passes chunks from `read` to `fn` until the end of output.
+func stream(read func() ([]byte, error), fn func(chunk []byte) error) error {
+	for {
+		chunk, err := read()
+		if len(chunk) > 0 {
+			if err := fn(chunk); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// StreamBuffering controls how streamed output is chunked.
+type StreamBuffering int
+
+const (
+	// BlockBuffering delivers output in chunks of up to 32 KiB, as soon as
+	// they are available. It has the best throughput.
+	BlockBuffering StreamBuffering = iota
+
+	// LineBuffering delivers output line by line, as soon as each line is
+	// complete. It has the lowest latency, e.g. for real-time display, at
+	// the cost of a callback per line. Lines longer than 32 KiB are split.
+	LineBuffering
+)
+
+// reader returns a function reading the next chunk from `r`.
+func (b StreamBuffering) reader(r io.Reader) func() ([]byte, error) {
+	if b == LineBuffering {
+		br := bufio.NewReaderSize(r, streamChunkSize)
+		return func() ([]byte, error) {
+			line, err := br.ReadSlice('\n')
+			if err == bufio.ErrBufferFull {
+				err = nil
+			}
+			return line, err
+		}
+	}
+
+	buf := make([]byte, streamChunkSize)
+	return func() ([]byte, error) {
+		n, err := r.Read(buf)
+		return buf[:n], err
+	}
+}
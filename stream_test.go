@@ -0,0 +1,227 @@
+package pdftotext
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pagesRunner returns a fake converting a document of `total` pages, each to
+// its number on a line, up to the last page set with `-l`.
+func pagesRunner(total int) *fakeRunner {
+	return &fakeRunner{respond: func(args []string) fakeResult {
+		last := total
+		if l, err := strconv.Atoi(flagValue(args, "-l")); err == nil {
+			last = min(l, total)
+		}
+
+		var out strings.Builder
+		for p := 1; p <= last; p++ {
+			fmt.Fprintf(&out, "%d\n\f", p)
+		}
+
+		return fakeResult{stdout: out.String()}
+	}}
+}
+
+func TestMaxPagesFast(t *testing.T) {
+	convs := map[string]func(*Command) ([]string, error){
+		"Run": func(c *Command) ([]string, error) {
+			out, err := c.RunString(context.Background(), "in.pdf")
+			return splitPages(out), err
+		},
+		"RunTo": func(c *Command) ([]string, error) {
+			var buf bytes.Buffer
+			err := c.RunTo(context.Background(), "in.pdf", &buf)
+			return splitPages(buf.String()), err
+		},
+		"RunStream": func(c *Command) ([]string, error) {
+			r, err := c.RunStream(context.Background(), "in.pdf")
+			if err != nil {
+				return nil, err
+			}
+			b, err := io.ReadAll(r)
+			if cerr := r.Close(); cerr != err {
+				return nil, fmt.Errorf("Close() = %v, want %v", cerr, err)
+			}
+			return splitPages(string(b)), err
+		},
+		"RunEachPage": func(c *Command) ([]string, error) {
+			var pages []string
+			err := c.RunEachPage(context.Background(), "in.pdf", func(page Page) error {
+				pages = append(pages, page.Text)
+				return nil
+			})
+			return pages, err
+		},
+		"RunPages": func(c *Command) ([]string, error) {
+			return c.RunPages(context.Background(), "in.pdf")
+		},
+		"Chunks": func(c *Command) ([]string, error) {
+			chunks, err := c.Chunks(context.Background(), "in.pdf", 1, 0)
+			var pages []string
+			for _, ch := range chunks {
+				pages = append(pages, ch.Text)
+			}
+			return pages, err
+		},
+	}
+
+	tests := []struct {
+		total   int
+		max     uint64
+		want    []string
+		wantErr error
+	}{
+		{total: 1, max: 2, want: []string{"1"}},
+		{total: 2, max: 2, want: []string{"1", "2"}},
+		{total: 3, max: 2, want: []string{"1", "2"}, wantErr: ErrTooManyPages},
+		{total: 100, max: 1, want: []string{"1"}, wantErr: ErrTooManyPages},
+	}
+
+	for name, conv := range convs {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/%d of %d", name, tt.total, tt.max), func(t *testing.T) {
+				cmd := newFake(t, pagesRunner(tt.total), WithMaxPagesFast(tt.max))
+
+				got, err := conv(cmd)
+				for i := range got {
+					got[i] = strings.TrimSpace(got[i])
+				}
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("pages = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestMaxPagesFastZero(t *testing.T) {
+	if _, err := NewCommand(WithRunner(&fakeRunner{}), WithMaxPagesFast(0)); err == nil {
+		t.Fatal("NewCommand() error = nil, want error")
+	}
+}
+
+func TestPageLimitReader(t *testing.T) {
+	// the limit is found within and across reads of any size
+	for size := 1; size <= 8; size++ {
+		l := &pageLimitReader{r: io.LimitReader(strings.NewReader("ab\fc\fd\f"), 100), left: 2}
+
+		var got []byte
+		buf := make([]byte, size)
+		var err error
+		for err == nil {
+			var n int
+			n, err = l.Read(buf)
+			got = append(got, buf[:n]...)
+		}
+
+		if err != ErrTooManyPages || string(got) != "ab\fc\f" {
+			t.Errorf("size %d: got %q, %v, want %q, %v", size, got, err, "ab\fc\f", ErrTooManyPages)
+		}
+	}
+}
+
+func TestRunFuncBounded(t *testing.T) {
+	const size = 64 << 20 // output of 64 MiB
+
+	var written atomic.Int64
+	runner := RunnerFunc(func(ctx context.Context, cmd *exec.Cmd) error {
+		line := []byte(strings.Repeat("x", 1023) + "\n")
+		for written.Load() < size {
+			n, err := cmd.Stdout.Write(line)
+			written.Add(int64(n))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	cmd, err := NewCommand(WithRunner(runner))
+	if err != nil {
+		t.Fatalf("NewCommand() error = %v", err)
+	}
+
+	var before, peak runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	var read int64
+	err = cmd.RunFunc(context.Background(), "in.pdf", func(chunk []byte) error {
+		if len(chunk) > streamChunkSize {
+			t.Fatalf("chunk of %d bytes, want at most %d", len(chunk), streamChunkSize)
+		}
+		read += int64(len(chunk))
+
+		// the process is blocked while the chunk is processed
+		if read == int64(len(chunk)) {
+			time.Sleep(50 * time.Millisecond)
+			if ahead := written.Load() - read; ahead > 2*streamChunkSize {
+				t.Errorf("process wrote %d bytes ahead of a slow callback", ahead)
+			}
+		}
+		if read%(8<<20) < int64(len(chunk)) {
+			runtime.ReadMemStats(&peak)
+			if grown := int64(peak.HeapAlloc) - int64(before.HeapAlloc); grown > 8<<20 {
+				t.Fatalf("heap grew by %d bytes after %d bytes of output", grown, read)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunFunc() error = %v", err)
+	}
+	if read != size {
+		t.Errorf("read %d bytes, want %d", read, size)
+	}
+}
+
+func BenchmarkRunFuncBuffering(b *testing.B) {
+	out := strings.Repeat(strings.Repeat("a line of some sixty characters of text, as in a document\n", 60)+"\f", 200)
+
+	for _, buffering := range []struct {
+		name string
+		mode StreamBuffering
+	}{
+		{"block", BlockBuffering},
+		{"line", LineBuffering},
+	} {
+		cmd, err := NewCommand(WithRunner(&fakeRunner{respond: output(out)}), WithStreamBuffering(buffering.mode))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(buffering.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(out)))
+
+			for i := 0; i < b.N; i++ {
+				chunks := 0
+				err := cmd.RunFunc(context.Background(), "in.pdf", func([]byte) error {
+					chunks++
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				b.ReportMetric(float64(chunks), "chunks/op")
+			}
+		})
+	}
+}
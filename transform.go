@@ -0,0 +1,383 @@
+package pdftotext
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` output transforms
+// ----------------------------------------------------------------------------
+
+// Transform post-processes the output of `pdftotext`. It wraps the output
+// reader, so the output is transformed lazily, as it is read.
+type Transform func(r io.Reader) io.Reader
+
+// transform applies output transforms of the command to `r`, in order, after
+// transcoding it to UTF-8 with `WithTranscodeToUTF8` and removing the BOM with
+// `WithUnicodeBOMStripping`.
+func (c *Command) transform(r io.Reader) io.Reader {
+	if c.transcode {
+		enc, _ := decoder(c.encoding) // validated by `NewCommand`
+
+		var dec transform.Transformer = enc.NewDecoder()
+		if slices.Contains(c.args, "-bom") {
+			// the BOM of the source encoding, if any, is replaced with
+			// a UTF-8 one, so there is exactly one
+			dec = transform.Chain(dec, unicode.UTF8BOM.NewDecoder(), unicode.UTF8BOM.NewEncoder())
+		}
+
+		r = &transcodeReader{src: r, dec: dec}
+	}
+
+	if c.stripBOM {
+		enc := c.encoding
+		if c.transcode {
+			enc = "UTF-8"
+		}
+		r = StripBOM(enc)(r)
+	}
+
+	for _, t := range c.transforms {
+		r = t(r)
+	}
+
+	return r
+}
+
+// transcodeReader decodes the wrapped reader into UTF-8, as the bytes are read.
+type transcodeReader struct {
+	src io.Reader
+	dec transform.Transformer
+	r   io.Reader // decoding reader, set on the first read
+}
+
+func (r *transcodeReader) Read(p []byte) (int, error) {
+	if r.r == nil {
+		r.r = transform.NewReader(r.src, r.dec)
+	}
+
+	return r.r.Read(p)
+}
+
+// WriteTo decodes the output straight into `w`, e.g. for `io.Copy`, so the
+// wrapped reader writes into the decoder without an intermediate buffer.
+func (r *transcodeReader) WriteTo(w io.Writer) (int64, error) {
+	if r.r != nil {
+		// already partially decoded by `Read`
+		return io.Copy(w, r.r)
+	}
+
+	cw := &countWriter{w: w}
+	tw := transform.NewWriter(cw, r.dec)
+	r.r = bytes.NewReader(nil)
+
+	_, err := io.Copy(tw, r.src)
+	if cerr := tw.Close(); err == nil {
+		err = cerr
+	}
+
+	return cw.n, err
+}
+
+// countWriter counts bytes written to the wrapped writer.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+
+	return n, err
+}
+
+// replaceReader replaces every occurrence of `old` with `new` in the wrapped
+// reader, as the bytes are read.
+type replaceReader struct {
+	r   io.Reader
+	old []byte
+	new []byte
+
+	tmp []byte // read buffer, reused
+	buf []byte // read, but not yet replaced
+	out []byte // replaced, but not yet returned
+	err error
+}
+
+func newReplaceReader(r io.Reader, old, new []byte) *replaceReader {
+	return &replaceReader{r: r, old: old, new: new}
+}
+
+func (r *replaceReader) Read(p []byte) (int, error) {
+	if err := r.fill(); err != nil {
+		return 0, err
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+
+	return n, nil
+}
+
+// WriteTo writes the replaced bytes to `w` as they are produced, without
+// copying them into an intermediate buffer, e.g. for `io.Copy`.
+func (r *replaceReader) WriteTo(w io.Writer) (int64, error) {
+	return writeOut(w, r.fill, &r.out)
+}
+
+// fill reads from the wrapped reader until there are replaced bytes to return,
+// or the error of the wrapped reader.
+func (r *replaceReader) fill() error {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return r.err
+		}
+
+		if r.tmp == nil {
+			r.tmp = make([]byte, streamChunkSize)
+		}
+
+		n, err := r.r.Read(r.tmp)
+		r.buf = append(r.buf, r.tmp[:n]...)
+		r.err = err
+		r.replace()
+	}
+
+	return nil
+}
+
+func (r *replaceReader) replace() {
+	for {
+		i := bytes.Index(r.buf, r.old)
+		if i < 0 {
+			break
+		}
+
+		r.out = append(r.out, r.buf[:i]...)
+		r.out = append(r.out, r.new...)
+		r.buf = r.buf[i+len(r.old):]
+	}
+
+	// keep what may turn out to be an occurrence with the next read
+	keep := 0
+	if r.err == nil {
+		for k := min(len(r.old)-1, len(r.buf)); k > 0; k-- {
+			if bytes.HasPrefix(r.old, r.buf[len(r.buf)-k:]) {
+				keep = k
+				break
+			}
+		}
+	}
+
+	r.out = append(r.out, r.buf[:len(r.buf)-keep]...)
+	r.buf = append([]byte(nil), r.buf[len(r.buf)-keep:]...)
+}
+
+// writeOut writes `*out` to `w` each time `fill` produces it, until `fill`
+// returns an error. `io.EOF` means all was written.
+func writeOut(w io.Writer, fill func() error, out *[]byte) (int64, error) {
+	var total int64
+	for {
+		if err := fill(); err != nil {
+			if err == io.EOF {
+				return total, nil
+			}
+			return total, err
+		}
+
+		n, err := w.Write(*out)
+		total += int64(n)
+		*out = (*out)[n:]
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// boms are the byte order markers removed by `StripBOM`, by encoding.
+var boms = map[string][][]byte{
+	"UTF-8":    {{0xEF, 0xBB, 0xBF}},
+	"UTF-16":   {{0xFF, 0xFE}, {0xFE, 0xFF}},
+	"UTF-16LE": {{0xFF, 0xFE}},
+	"UTF-16BE": {{0xFE, 0xFF}},
+	"UCS-2":    {{0xFF, 0xFE}, {0xFE, 0xFF}},
+}
+
+// StripBOM returns a transform removing a leading byte order marker (BOM) from
+// output in `encoding`, i.e. "UTF-8" or a UTF-16 one. Output without one, or
+// in other encodings, e.g. "Latin1" where "\xFF\xFE" is "ÿþ", is left as is.
+func StripBOM(encoding string) Transform {
+	for name, marks := range boms {
+		if strings.EqualFold(name, encoding) {
+			return func(r io.Reader) io.Reader {
+				return &bomReader{r: r, boms: marks}
+			}
+		}
+	}
+
+	return func(r io.Reader) io.Reader { return r }
+}
+
+// bomReader removes a leading byte order marker on the first read.
+type bomReader struct {
+	r    io.Reader
+	boms [][]byte // of the same length
+	head bool     // the start was checked for a BOM
+}
+
+func (r *bomReader) Read(p []byte) (int, error) {
+	if err := r.strip(); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
+
+// WriteTo writes the output to `w`, passing it on as is once the BOM is
+// removed, e.g. for `io.Copy`.
+func (r *bomReader) WriteTo(w io.Writer) (int64, error) {
+	if err := r.strip(); err != nil {
+		return 0, err
+	}
+
+	return io.Copy(w, r.r)
+}
+
+// strip removes the BOM from the start of the output, once.
+func (r *bomReader) strip() error {
+	if !r.head {
+		r.head = true
+
+		buf := make([]byte, len(r.boms[0]))
+		n, err := io.ReadFull(r.r, buf)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+
+		buf = buf[:n]
+		for _, bom := range r.boms {
+			if bytes.HasPrefix(buf, bom) {
+				buf = buf[len(bom):]
+				break
+			}
+		}
+
+		r.r = io.MultiReader(bytes.NewReader(buf), r.r)
+	}
+
+	return nil
+}
+
+// LineNumbering configures line numbers added by `LineNumbers`.
+type LineNumbering struct {
+	Start     int    // number of the first line
+	Width     int    // minimum width, numbers are right-aligned
+	Separator string // between the number and the line, defaults to " "
+	PerPage   bool   // restart numbering at `Start` on each page
+}
+
+// LineNumbers returns a transform prefixing every line with its number. Lines
+// may end with "\n", "\r\n" or "\r". Page breaks aren't part of any line.
+func LineNumbers(cfg LineNumbering) Transform {
+	if cfg.Separator == "" {
+		cfg.Separator = " "
+	}
+
+	return func(r io.Reader) io.Reader {
+		return &lineNumberReader{r: r, cfg: cfg, num: cfg.Start, start: true}
+	}
+}
+
+type lineNumberReader struct {
+	r   io.Reader
+	cfg LineNumbering
+
+	num   int  // number of the next line
+	start bool // at the start of a line
+	cr    bool // previous byte was "\r"
+
+	tmp []byte // read buffer, reused
+	out []byte // numbered, but not yet returned
+	err error
+}
+
+func (r *lineNumberReader) Read(p []byte) (int, error) {
+	if err := r.fill(); err != nil {
+		return 0, err
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+
+	return n, nil
+}
+
+// WriteTo writes the numbered lines to `w` as they are produced, without
+// copying them into an intermediate buffer, e.g. for `io.Copy`.
+func (r *lineNumberReader) WriteTo(w io.Writer) (int64, error) {
+	return writeOut(w, r.fill, &r.out)
+}
+
+// fill reads from the wrapped reader until there are numbered lines to return,
+// or the error of the wrapped reader.
+func (r *lineNumberReader) fill() error {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return r.err
+		}
+
+		if r.tmp == nil {
+			r.tmp = make([]byte, streamChunkSize)
+		}
+
+		n, err := r.r.Read(r.tmp)
+		r.number(r.tmp[:n])
+		r.err = err
+	}
+
+	return nil
+}
+
+func (r *lineNumberReader) number(in []byte) {
+	for _, b := range in {
+		if r.cr && b == '\n' {
+			r.out = append(r.out, b)
+			r.cr = false
+			continue
+		}
+		r.cr = false
+
+		// a page break also ends a line without a line ending
+		if b == pageBreak[0] {
+			r.out = append(r.out, b)
+			r.start = true
+			if r.cfg.PerPage {
+				r.num = r.cfg.Start
+			}
+			continue
+		}
+
+		if r.start {
+			r.out = fmt.Appendf(r.out, "%*d%s", r.cfg.Width, r.num, r.cfg.Separator)
+			r.num++
+			r.start = false
+		}
+
+		r.out = append(r.out, b)
+
+		switch b {
+		case '\n':
+			r.start = true
+		case '\r':
+			r.start, r.cr = true, true
+		}
+	}
+}
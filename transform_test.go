@@ -0,0 +1,409 @@
+package pdftotext
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// readerOnly hides any `io.WriterTo` of the wrapped reader from `io.Copy`.
+type readerOnly struct {
+	io.Reader
+}
+
+// writerOnly hides any `io.ReaderFrom` of the wrapped writer from `io.Copy`.
+type writerOnly struct {
+	io.Writer
+}
+
+// transformOptions are options wrapping the output in each of the transforms.
+var transformOptions = map[string][]option{
+	"none":            nil,
+	"transcode":       {WithTranscodeToUTF8()},
+	"BOM":             {WithEncoding("UTF-8"), WithUnicodeBOMStripping()},
+	"replace":         {WithPageSeparator("\n--\n")},
+	"numbers":         {WithLineNumbers(1)},
+	"skip":            {WithSkipLeadingPages(IsBlankPage, nil)},
+	"all":             {WithTranscodeToUTF8(), WithUnicodeBOMStripping(), WithSkipLeadingPages(IsBlankPage, nil), WithLineNumbers(1), WithPageSeparator("\n--\n")},
+	"transcode UTF-8": {WithEncoding("UTF-8"), WithTranscodeToUTF8()},
+}
+
+func TestRunWriterTo(t *testing.T) {
+	out := "\xef\xbb\xbf \n\fcaf\xe9 one\ntwo\r\nthree\f\ffour\f"
+
+	// exact output of some of the chains; the others are compared between
+	// the read paths only
+	outputs := map[string]string{
+		"none": out,
+		"BOM":  " \n\fcaf\xe9 one\ntwo\r\nthree\f\ffour\f",
+		"skip": out,
+		// decoded from Latin1, the UTF-8 BOM is no longer a BOM, nor blank
+		"all": "1 \u00ef\u00bb\u00bf \n\n--\n2 café one\n3 two\r\n4 three\n--\n\n--\n5 four\n--\n",
+	}
+
+	for name, opts := range transformOptions {
+		t.Run(name, func(t *testing.T) {
+			cmd := newFake(t, &fakeRunner{respond: output(out)}, opts...)
+
+			r, err := cmd.Run(context.Background(), "in.pdf")
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if _, ok := r.(io.WriterTo); !ok {
+				t.Fatalf("Run() = %T, want io.WriterTo", r)
+			}
+
+			var got bytes.Buffer
+			n, err := io.Copy(&got, r)
+			if err != nil {
+				t.Fatalf("io.Copy() error = %v", err)
+			}
+			if n != int64(got.Len()) {
+				t.Errorf("io.Copy() = %d, wrote %d", n, got.Len())
+			}
+
+			r, _ = cmd.Run(context.Background(), "in.pdf")
+			want, err := io.ReadAll(readerOnly{r})
+			if err != nil {
+				t.Fatalf("io.ReadAll() error = %v", err)
+			}
+			if got.String() != string(want) {
+				t.Errorf("io.Copy() = %q, read %q", got.String(), want)
+			}
+			if want, ok := outputs[name]; ok && got.String() != want {
+				t.Errorf("output = %q, want %q", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestTranscodeReaderPartial(t *testing.T) {
+	cmd := newFake(t, &fakeRunner{respond: output("caf\xe9 au lait\f")}, WithTranscodeToUTF8())
+
+	r, err := cmd.Run(context.Background(), "in.pdf")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	head := make([]byte, 3)
+	if _, err := io.ReadFull(r, head); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	var rest strings.Builder
+	if _, err := io.Copy(&rest, r); err != nil {
+		t.Fatalf("io.Copy() error = %v", err)
+	}
+	if got := string(head) + rest.String(); got != "café au lait\f" {
+		t.Errorf("output = %q, want %q", got, "café au lait\f")
+	}
+}
+
+func BenchmarkRunCopy(b *testing.B) {
+	out := strings.Repeat(strings.Repeat("caf\xe9 au lait, s'il vous pla\xeet\n", 60)+"\f", 200)
+
+	for _, name := range []string{"none", "transcode", "skip", "all"} {
+		cmd, err := NewCommand(WithRunner(&fakeRunner{respond: output(out)}))
+		if err != nil {
+			b.Fatal(err)
+		}
+		cmd, err = cmd.with(context.Background(), transformOptions[name]...)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		// the writer's own buffer is hidden, as of most writers, e.g. a socket
+		w := writerOnly{io.Discard}
+		copies := map[string]func(r io.Reader) (int64, error){
+			"WriterTo": func(r io.Reader) (int64, error) { return io.Copy(w, r) },
+			"Read":     func(r io.Reader) (int64, error) { return io.Copy(w, readerOnly{r}) },
+		}
+		for _, copy := range []string{"WriterTo", "Read"} {
+			b.Run(name+"/"+copy, func(b *testing.B) {
+				b.ReportAllocs()
+				b.SetBytes(int64(len(out)))
+
+				for i := 0; i < b.N; i++ {
+					r, err := cmd.Run(context.Background(), "in.pdf")
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := copies[copy](r); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestStripBOM(t *testing.T) {
+	tests := []struct {
+		name string
+		enc  string
+		in   string
+		want string
+	}{
+		{"UTF-8", "UTF-8", "\xef\xbb\xbftext\f", "text\f"},
+		{"UTF-16 LE", "UTF-16", "\xff\xfet\x00\f\x00", "t\x00\f\x00"},
+		{"UTF-16 BE", "UTF-16", "\xfe\xff\x00t\x00\f", "\x00t\x00\f"},
+		{"UTF-16LE", "UTF-16LE", "\xff\xfet\x00\f\x00", "t\x00\f\x00"},
+		{"UTF-16LE not BE", "UTF-16LE", "\xfe\xff\x00t\x00\f", "\xfe\xff\x00t\x00\f"},
+		{"UCS-2", "UCS-2", "\xfe\xff\x00t\x00\f", "\x00t\x00\f"},
+		{"case", "utf-8", "\xef\xbb\xbftext\f", "text\f"},
+		{"UTF-8 not UTF-16", "UTF-8", "\xff\xfetext\f", "\xff\xfetext\f"},
+		{"Latin1", "Latin1", "\xff\xfetext\f", "\xff\xfetext\f"},
+		{"default", "", "\xef\xbb\xbftext\f", "\xef\xbb\xbftext\f"},
+		{"none", "UTF-8", "text\f", "text\f"},
+		{"short", "UTF-8", "t", "t"},
+		{"empty", "UTF-8", "", ""},
+		{"BOM only", "UTF-8", "\xef\xbb\xbf", ""},
+		{"partial BOM", "UTF-8", "\xef\xbbtext", "\xef\xbbtext"},
+		{"second BOM", "UTF-8", "\xef\xbb\xbf\xef\xbb\xbftext", "\xef\xbb\xbftext"},
+		{"not leading", "UTF-8", "text\xef\xbb\xbf", "text\xef\xbb\xbf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(readerOnly{StripBOM(tt.enc)(iotest.OneByteReader(strings.NewReader(tt.in)))})
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Read() = %q, want %q", got, tt.want)
+			}
+
+			var buf bytes.Buffer
+			if _, err := io.Copy(&buf, StripBOM(tt.enc)(strings.NewReader(tt.in))); err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("WriteTo() = %q, want %q", buf.String(), tt.want)
+			}
+
+			opts := []option{WithUnicodeBOMStripping()}
+			if tt.enc != "" {
+				opts = append(opts, WithEncoding(tt.enc))
+			}
+			cmd := newFake(t, &fakeRunner{respond: output(tt.in)}, opts...)
+			if out, _ := cmd.RunString(context.Background(), "in.pdf"); out != tt.want {
+				t.Errorf("RunString() = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithUnknownCharReplacement(t *testing.T) {
+	tests := []struct {
+		name   string
+		banner string
+		output string
+		want   string
+	}{
+		// Poppler emits U+FFFD for glyphs without Unicode mapping
+		{"Poppler", popplerBanner, "caf\uFFFD \uFFFD\uFFFD\f", "caf? ??\f"},
+		// Xpdf drops them, so there is nothing to replace
+		{"Xpdf", xpdfBanner, "caf \f", "caf \f"},
+		{"none", popplerBanner, "café\f", "café\f"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{banner: tt.banner, respond: output(tt.output)}
+			cmd := newFake(t, f, WithEncoding("UTF-8"), WithUnknownCharReplacement('?'))
+
+			got, err := cmd.RunString(context.Background(), "in.pdf")
+			if err != nil {
+				t.Fatalf("RunString() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RunString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithUnknownCharReplacementSplit(t *testing.T) {
+	out := "a\uFFFDb\uFFFD\f\xef\xbf"
+
+	// each byte is written, and so read, on its own
+	runner := RunnerFunc(func(ctx context.Context, cmd *exec.Cmd) error {
+		for i := range len(out) {
+			if _, err := cmd.Stdout.Write([]byte{out[i]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	cmd, err := NewCommand(WithRunner(runner), WithUnknownCharReplacement('_'))
+	if err != nil {
+		t.Fatalf("NewCommand() error = %v", err)
+	}
+
+	var got strings.Builder
+	if err := cmd.RunTo(context.Background(), "in.pdf", &got); err != nil {
+		t.Fatalf("RunTo() error = %v", err)
+	}
+
+	// a truncated marker at the end of the output is kept as is
+	if want := "a_b_\f\xef\xbf"; got.String() != want {
+		t.Errorf("RunTo() = %q, want %q", got.String(), want)
+	}
+
+	r := newReplaceReader(iotest.OneByteReader(strings.NewReader(out)), []byte("\uFFFD"), []byte("_"))
+	if b, _ := io.ReadAll(r); string(b) != "a_b_\f\xef\xbf" {
+		t.Errorf("Read() = %q, want %q", b, "a_b_\f\xef\xbf")
+	}
+}
+
+func TestLineNumbers(t *testing.T) {
+	perPage := LineNumbering{Start: 1, Width: 3, Separator: ": ", PerPage: true}
+
+	tests := []struct {
+		name string
+		cfg  LineNumbering
+		in   string
+		want string
+	}{
+		{"LF", LineNumbering{Start: 1}, "one\ntwo\n\fthree\n\f", "1 one\n2 two\n\f3 three\n\f"},
+		{"CRLF", LineNumbering{Start: 1}, "one\r\ntwo\r\n\fthree\r\n\f", "1 one\r\n2 two\r\n\f3 three\r\n\f"},
+		{"CR", LineNumbering{Start: 1}, "one\rtwo\r\fthree\r\f", "1 one\r2 two\r\f3 three\r\f"},
+		{"per page LF", perPage, "one\ntwo\n\fthree\n\f", "  1: one\n  2: two\n\f  1: three\n\f"},
+		{"per page CRLF", perPage, "one\r\ntwo\r\n\fthree\r\n\f", "  1: one\r\n  2: two\r\n\f  1: three\r\n\f"},
+		{"per page CR", perPage, "one\rtwo\r\fthree\r\f", "  1: one\r  2: two\r\f  1: three\r\f"},
+		{"start", LineNumbering{Start: 9, Width: 2}, "a\nb\n", " 9 a\n10 b\n"},
+		{"empty lines", LineNumbering{Start: 1}, "a\n\nb\f", "1 a\n2 \n3 b\f"},
+		{"blank page", LineNumbering{Start: 1}, "a\n\f\fb\n\f", "1 a\n\f\f2 b\n\f"},
+		{"without trailing newline", LineNumbering{Start: 1}, "a\nb", "1 a\n2 b"},
+		{"page without trailing newline", LineNumbering{Start: 1}, "a\fb\f", "1 a\f2 b\f"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(LineNumbers(tt.cfg)(strings.NewReader(tt.in)))
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("LineNumbers() = %q, want %q", got, tt.want)
+			}
+
+			// "\r\n" split across reads is a single line ending
+			got, err = io.ReadAll(LineNumbers(tt.cfg)(iotest.OneByteReader(strings.NewReader(tt.in))))
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("LineNumbers() byte by byte = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithLineNumbers(t *testing.T) {
+	cmd := newFake(t, &fakeRunner{respond: output("first\nsecond\n\fthird\r\n\f")}, WithLineNumbers(1))
+
+	got, err := cmd.RunString(context.Background(), "in.pdf")
+	if err != nil {
+		t.Fatalf("RunString() error = %v", err)
+	}
+
+	// numbering continues across pages
+	if want := "1 first\n2 second\n\f3 third\r\n\f"; got != want {
+		t.Errorf("RunString() = %q, want %q", got, want)
+	}
+}
+
+func TestWithPageSeparator(t *testing.T) {
+	out := "one\n\ftwo\n\f\fthree\n\f"
+
+	tests := []struct {
+		sep  string
+		want string
+	}{
+		{"\n\n", "one\n\n\ntwo\n\n\n\n\nthree\n\n\n"},
+		{"\n--- page ---\n", "one\n\n--- page ---\ntwo\n\n--- page ---\n\n--- page ---\nthree\n\n--- page ---\n"},
+		{"", "one\ntwo\nthree\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.sep), func(t *testing.T) {
+			cmd := newFake(t, &fakeRunner{respond: output(out)}, WithPageSeparator(tt.sep))
+
+			got, err := cmd.RunString(context.Background(), "in.pdf")
+			if err != nil {
+				t.Fatalf("RunString() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RunString() = %q, want %q", got, tt.want)
+			}
+
+			var sb strings.Builder
+			if err := cmd.RunTo(context.Background(), "in.pdf", &sb); err != nil {
+				t.Fatalf("RunTo() error = %v", err)
+			}
+			if sb.String() != tt.want {
+				t.Errorf("RunTo() = %q, want %q", sb.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestByteOrderMarkerTranscoding(t *testing.T) {
+	// "café\f" in each encoding, with the BOM `pdftotext -bom` writes for it
+	texts := map[string][2]string{
+		"Latin1": {"", "caf\xe9\f"},
+		"UTF-8":  {"\xef\xbb\xbf", "café\f"},
+		"UCS-2":  {"\xfe\xff", "\x00c\x00a\x00f\x00\xe9\x00\f"},
+	}
+	f := &fakeRunner{respond: func(args []string) fakeResult {
+		text := texts[flagValue(args, "-enc")]
+		if slices.Contains(args, "-bom") {
+			return fakeResult{stdout: text[0] + text[1]}
+		}
+		return fakeResult{stdout: text[1]}
+	}}
+
+	tests := []struct {
+		name string
+		opts []option
+		want string
+	}{
+		{"BOM", []option{WithByteOrderMarker()}, "\xef\xbb\xbfcafé\f"},
+		{"BOM before transcoding", []option{WithByteOrderMarker(), WithTranscodeToUTF8()}, "\xef\xbb\xbfcafé\f"},
+		{"no BOM", nil, "café\f"},
+		{"BOM stripped", []option{WithByteOrderMarker(), WithUnicodeBOMStripping()}, "café\f"},
+	}
+
+	for enc := range texts {
+		for _, tt := range tests {
+			t.Run(enc+"/"+tt.name, func(t *testing.T) {
+				opts := append([]option{WithEncoding(enc), WithTranscodeToUTF8()}, tt.opts...)
+				cmd := newFake(t, f, opts...)
+
+				got, err := cmd.RunString(context.Background(), "in.pdf")
+				if err != nil {
+					t.Fatalf("RunString() error = %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("RunString() = %q, want %q", got, tt.want)
+				}
+
+				var sb strings.Builder
+				if err := cmd.RunTo(context.Background(), "in.pdf", writerOnly{&sb}); err != nil {
+					t.Fatalf("RunTo() error = %v", err)
+				}
+				if sb.String() != tt.want {
+					t.Errorf("RunTo() = %q, want %q", sb.String(), tt.want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package pdftotext
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` TSV
+// ----------------------------------------------------------------------------
+
+// TSVRow is a row of `pdftotext -tsv` output, in the format of Tesseract TSV.
+// Positions are in points from the top-left corner of the page.
+//
+// Level 5 rows are words; rows of other levels, i.e. pages, blocks, paragraphs
+// and lines, have their text set to markers, e.g. "###PAGE###".
+type TSVRow struct {
+	Level    int
+	PageNum  int
+	BlockNum int
+	ParNum   int
+	LineNum  int
+	WordNum  int
+	Left     float64
+	Top      float64
+	Width    float64
+	Height   float64
+	Conf     float64
+	Text     string
+}
+
+// tsvColumns are the columns of `pdftotext -tsv` output, in the default order.
+var tsvColumns = []string{
+	"level", "page_num", "par_num", "block_num", "line_num", "word_num",
+	"left", "top", "width", "height", "conf", "text",
+}
+
+// RunTSV executes prepared `pdftotext` command with `-tsv` and returns the rows
+// of the output, e.g. word geometry for layout analysis.
+//
+// The `-tsv` option is only supported by Poppler, so it fails for Xpdf.
+// Malformed lines are skipped, and reported together in an error returned
+// along with the rows.
+func (c *Command) RunTSV(ctx context.Context, inpath string) ([]TSVRow, error) {
+	if err := c.require(ctx, requirement{"-tsv", VariantPoppler}); err != nil {
+		return nil, err
+	}
+
+	inpath, err := c.inpath(inpath)
+	if err != nil {
+		return nil, err
+	}
+
+	out, _, err := c.withArgs("-tsv").exec(ctx, inpath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTSV(out)
+}
+
+// parseTSV parses rows of `pdftotext -tsv` output. Columns are found by the
+// header row, if any.
+func parseTSV(out []byte) ([]TSVRow, error) {
+	var (
+		rows []TSVRow
+		errs []error
+		cols = tsvColumns
+	)
+
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(nil, 1024*1024)
+
+	for num := 1; sc.Scan(); num++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, "\t", len(cols))
+		if num == 1 && fields[0] == "level" {
+			cols = fields
+			continue
+		}
+
+		row, err := parseTSVRow(cols, fields)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", num, err))
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+	if err := sc.Err(); err != nil {
+		return rows, err
+	}
+
+	if len(errs) > 0 {
+		return rows, fmt.Errorf("pdftotext: skipped %d malformed TSV lines: %w", len(errs), errors.Join(errs...))
+	}
+
+	return rows, nil
+}
+
+// parseTSVRow parses `fields` of a row, named by `cols`.
+func parseTSVRow(cols, fields []string) (TSVRow, error) {
+	var row TSVRow
+
+	if len(fields) != len(cols) {
+		return row, fmt.Errorf("expected %d columns, got %d", len(cols), len(fields))
+	}
+
+	ints := map[string]*int{
+		"level":     &row.Level,
+		"page_num":  &row.PageNum,
+		"block_num": &row.BlockNum,
+		"par_num":   &row.ParNum,
+		"line_num":  &row.LineNum,
+		"word_num":  &row.WordNum,
+	}
+	floats := map[string]*float64{
+		"left":   &row.Left,
+		"top":    &row.Top,
+		"width":  &row.Width,
+		"height": &row.Height,
+		"conf":   &row.Conf,
+	}
+
+	for i, col := range cols {
+		var err error
+
+		switch {
+		case col == "text":
+			row.Text = fields[i]
+		case ints[col] != nil:
+			*ints[col], err = strconv.Atoi(fields[i])
+		case floats[col] != nil:
+			*floats[col], err = strconv.ParseFloat(fields[i], 64)
+		}
+		if err != nil {
+			return row, fmt.Errorf("invalid %s: %w", col, err)
+		}
+	}
+
+	return row, nil
+}
@@ -0,0 +1,124 @@
+package pdftotext
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTSV(t *testing.T) {
+	header := "level\tpage_num\tpar_num\tblock_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n"
+	page := TSVRow{Level: 1, PageNum: 1, Width: 612, Height: 792, Conf: -1, Text: "###PAGE###"}
+	word := TSVRow{Level: 5, PageNum: 1, BlockNum: 2, ParNum: 1, LineNum: 3, WordNum: 4, Left: 72.5, Top: 90, Width: 30.25, Height: 12, Conf: 100, Text: "Hello"}
+
+	tests := []struct {
+		name string
+		in   string
+		want []TSVRow
+		errs []string // lines reported as malformed
+	}{
+		{"empty", "", nil, nil},
+		{
+			"header",
+			header +
+				"1\t1\t0\t0\t0\t0\t0\t0\t612\t792\t-1\t###PAGE###\n" +
+				"5\t1\t1\t2\t3\t4\t72.5\t90\t30.25\t12\t100\tHello\n",
+			[]TSVRow{page, word}, nil,
+		},
+		{
+			"without header",
+			"5\t1\t1\t2\t3\t4\t72.5\t90\t30.25\t12\t100\tHello\n",
+			[]TSVRow{word}, nil,
+		},
+		{
+			"header with other columns",
+			"level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+				"5\t1\t2\t1\t3\t4\t72.5\t90\t30.25\t12\t100\tHello\n",
+			[]TSVRow{word}, nil,
+		},
+		{
+			"CRLF and blank lines",
+			header + "\r\n5\t1\t1\t2\t3\t4\t72.5\t90\t30.25\t12\t100\tHello\r\n\n",
+			[]TSVRow{word}, nil,
+		},
+		{
+			"tab in text",
+			"5\t1\t1\t2\t3\t4\t72.5\t90\t30.25\t12\t100\tHel\tlo\n",
+			[]TSVRow{{Level: 5, PageNum: 1, BlockNum: 2, ParNum: 1, LineNum: 3, WordNum: 4, Left: 72.5, Top: 90, Width: 30.25, Height: 12, Conf: 100, Text: "Hel\tlo"}}, nil,
+		},
+		{
+			"malformed lines",
+			header +
+				"5\t1\t1\t2\t3\n" +
+				"5\t1\t1\t2\t3\t4\t72.5\t90\t30.25\t12\t100\tHello\n" +
+				"x\t1\t1\t2\t3\t4\t72.5\t90\t30.25\t12\t100\tHello\n" +
+				"5\t1\t1\t2\t3\t4\t72.5\t90\twide\t12\t100\tHello\n",
+			[]TSVRow{word}, []string{"line 2: expected 12 columns, got 5", "line 4: invalid level", "line 5: invalid width"},
+		},
+		{
+			"header not first",
+			"5\t1\t1\t2\t3\t4\t72.5\t90\t30.25\t12\t100\tHello\n" + header,
+			[]TSVRow{word}, []string{"line 2: invalid level"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTSV([]byte(tt.in))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTSV() = %+v, want %+v", got, tt.want)
+			}
+
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Errorf("parseTSV() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("parseTSV() error = nil, want %q", tt.errs)
+			}
+
+			lines := strings.Split(err.Error(), "\n")
+			if want := fmt.Sprintf("pdftotext: skipped %d malformed TSV lines: ", len(tt.errs)); !strings.HasPrefix(lines[0], want) {
+				t.Errorf("parseTSV() error = %q, want prefix %q", lines[0], want)
+			}
+			lines[0] = lines[0][strings.Index(lines[0], "line "):]
+			if len(lines) != len(tt.errs) {
+				t.Fatalf("parseTSV() errors = %q, want %q", lines, tt.errs)
+			}
+			for i, want := range tt.errs {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("parseTSV() error %d = %q, want prefix %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunTSV(t *testing.T) {
+	out := "level\tpage_num\tpar_num\tblock_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+		"5\t1\t0\t0\t0\t0\t1\t2\t3\t4\t100\tword\n"
+
+	f := &fakeRunner{respond: output(out)}
+	cmd := newFake(t, f)
+
+	rows, err := cmd.RunTSV(context.Background(), "in.pdf")
+	if err != nil {
+		t.Fatalf("RunTSV() error = %v", err)
+	}
+	if want := []TSVRow{{Level: 5, PageNum: 1, Left: 1, Top: 2, Width: 3, Height: 4, Conf: 100, Text: "word"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("RunTSV() = %+v, want %+v", rows, want)
+	}
+	if got, want := f.conversions(), [][]string{{"-tsv", "in.pdf", "-"}}; !slices.EqualFunc(got, want, slices.Equal[[]string]) {
+		t.Errorf("runs = %q, want %q", got, want)
+	}
+
+	xpdf := newFake(t, &fakeRunner{banner: xpdfBanner})
+	if _, err := xpdf.RunTSV(context.Background(), "in.pdf"); err == nil || !strings.Contains(err.Error(), "-tsv is supported only by Poppler") {
+		t.Errorf("RunTSV() error = %v, want unsupported by Xpdf", err)
+	}
+}
@@ -0,0 +1,143 @@
+package pdftotext
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` variant
+// ----------------------------------------------------------------------------
+
+// Variant is an implementation of `pdftotext`.
+type Variant int
+
+const (
+	VariantUnknown Variant = iota
+	VariantXpdf
+	VariantPoppler
+)
+
+func (v Variant) String() string {
+	switch v {
+	case VariantXpdf:
+		return "Xpdf"
+	case VariantPoppler:
+		return "Poppler"
+	default:
+		return "unknown"
+	}
+}
+
+// variants caches detected variants by executable path.
+var variants sync.Map
+
+// DetectVariant returns the implementation of `pdftotext` executable at `path`,
+// based on its version banner. If `path` is empty, the executable is searched
+// for as in `NewCommand`.
+//
+// The result is cached by path, so the executable is run only once.
+func DetectVariant(ctx context.Context, path string) (Variant, error) {
+	c, err := executable(path)
+	if err != nil {
+		return VariantUnknown, err
+	}
+
+	return c.variant(ctx)
+}
+
+// variant runs `pdftotext -v`, as in `DetectVariant`. Only results of the
+// default runner are cached, so a fake one can't affect other commands.
+func (c *Command) variant(ctx context.Context) (Variant, error) {
+	_, cache := c.runner.(execRunner)
+	if v, ok := variants.Load(c.path); ok && cache {
+		return v.(Variant), nil
+	}
+
+	out, err := c.banner(ctx)
+	if err != nil {
+		return VariantUnknown, err
+	}
+
+	v := VariantUnknown
+	switch {
+	// Poppler's banner also mentions Glyph & Cog, so check it first
+	case bytes.Contains(out, []byte("Poppler")):
+		v = VariantPoppler
+	case bytes.Contains(out, []byte("xpdf")), bytes.Contains(out, []byte("Glyph & Cog")):
+		v = VariantXpdf
+	}
+
+	if cache {
+		variants.Store(c.path, v)
+	}
+
+	return v, nil
+}
+
+// requirement is a flag supported by a single variant only.
+type requirement struct {
+	flag    string
+	variant Variant
+}
+
+// require returns an error if `flag` is not supported by the variant of the
+// command's executable. An unknown variant is assumed to support it.
+func (c *Command) require(ctx context.Context, req requirement) error {
+	v, err := c.variant(ctx)
+	if err != nil {
+		return err
+	}
+
+	if v != VariantUnknown && v != req.variant {
+		return fmt.Errorf("pdftotext: %s is supported only by %s, not by %s", req.flag, req.variant, v)
+	}
+
+	return nil
+}
+
+// ValidateAgainst checks that the options of the command are supported by the
+// `pdftotext` executable at `path`, without converting anything, e.g. to check
+// a configuration against the deployed executable at startup. If `path` is
+// empty, the executable is searched for as in `NewCommand`.
+//
+// The variant of the executable is detected as in `DetectVariant`, so it's
+// cached by path, and checks of options, e.g. of `WithValidatedEncoding`, are
+// run against it. The returned error lists all unsupported options.
+func (c *Command) ValidateAgainst(ctx context.Context, path string) error {
+	cc := c.Clone()
+	cc.path = path
+
+	// a custom runner gets the path as is, as in `NewCommand`
+	if _, ok := c.runner.(execRunner); ok {
+		var err error
+		if cc.path, err = resolvePath(path); err != nil {
+			return err
+		}
+	}
+
+	if _, err := cc.variant(ctx); err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, req := range cc.requires {
+		if slices.Contains(cc.requires[:i], req) {
+			continue // the option was applied more than once
+		}
+		if err := cc.require(ctx, req); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, check := range cc.checks {
+		if err := check(cc); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,133 @@
+package pdftotext
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"regexp"
+)
+
+// ----------------------------------------------------------------------------
+// -- `pdftotext` version
+// ----------------------------------------------------------------------------
+
+var versionNumber = regexp.MustCompile(`version (\d+(?:\.\d+)+)`)
+
+// Version returns the version of `pdftotext` executable at `path`, e.g. "4.05"
+// for Xpdf or "22.02.0" for Poppler. If `path` is empty, the executable is
+// searched for as in `NewCommand`.
+func Version(ctx context.Context, path string) (string, error) {
+	c, err := executable(path)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := c.banner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return parseVersion(out)
+}
+
+// parseVersion returns the version number from the version banner `out`.
+func parseVersion(out []byte) (string, error) {
+	m := versionNumber.FindSubmatch(out)
+	if m == nil {
+		return "", fmt.Errorf("pdftotext: can't parse version from %q", firstLine(out))
+	}
+
+	return string(m[1]), nil
+}
+
+// banner runs `pdftotext -v` and returns the printed version banner. Xpdf
+// prints it to standard output, Poppler to standard error.
+func (c *Command) banner(ctx context.Context) ([]byte, error) {
+	stdout, stderr, err := c.tool(ctx, "-v")
+	if ctx.Err() != nil {
+		return nil, err
+	}
+
+	// some versions exit with a non-zero code after printing the banner
+	out := append(stdout, stderr...)
+	if len(out) == 0 {
+		if err == nil {
+			err = errors.New("pdftotext: empty version output")
+		}
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// resolvePath returns the path of `pdftotext` executable at `path`, searching
+// for it as in `NewCommand` if `path` is empty.
+func resolvePath(path string) (string, error) {
+	if path == "" {
+		return lookPath()
+	}
+
+	return exec.LookPath(path)
+}
+
+// executable returns a bare command of `pdftotext` executable at `path`,
+// searched for as in `NewCommand` if `path` is empty, e.g. to run `-v`.
+func executable(path string) (*Command, error) {
+	path, err := resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Command{path: path, runner: execRunner{}}, nil
+}
+
+// firstLine returns the first line of `b`, without surrounding whitespace.
+func firstLine(b []byte) string {
+	line, _, _ := bytes.Cut(b, []byte("\n"))
+	return string(bytes.TrimSpace(line))
+}
+
+// checkExecutable returns an error if there's no executable file at `path`.
+func checkExecutable(path string) error {
+	fi, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("pdftotext: executable %s not found: %w", path, err)
+	}
+	if err != nil {
+		return fmt.Errorf("pdftotext: executable %s not accessible: %w", path, err)
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("pdftotext: executable %s is a directory", path)
+	}
+
+	return nil
+}
+
+// Ping checks that `pdftotext` executable of the command is usable, by running
+// a cheap `pdftotext -v`, e.g. as a health check on startup.
+//
+// The error tells apart a missing executable, one that can't be executed and
+// one that runs but fails.
+func (c *Command) Ping(ctx context.Context) error {
+	// the executable may not exist for a custom runner
+	if _, ok := c.runner.(execRunner); ok {
+		if err := checkExecutable(c.path); err != nil {
+			return err
+		}
+	}
+
+	stdout, stderr, err := c.tool(ctx, "-v")
+	if errors.Is(err, fs.ErrPermission) {
+		return fmt.Errorf("pdftotext: executable %s not executable: %w", c.path, err)
+	}
+	// some versions exit with a non-zero code after printing the banner
+	if err != nil && (ctx.Err() != nil || !versionNumber.Match(append(stdout, stderr...))) {
+		return fmt.Errorf("pdftotext: executable %s failed: %w", c.path, err)
+	}
+
+	return nil
+}
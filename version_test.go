@@ -0,0 +1,217 @@
+package pdftotext
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestToolInvocations checks that every invocation of `pdftotext` goes through
+// the runner, in the command's directory and environment.
+func TestToolInvocations(t *testing.T) {
+	dir := t.TempDir()
+	env := []string{"LANG=C", "HOME=/nonexistent"}
+
+	calls := []struct {
+		name string
+		call func(c *Command) error
+		args []string
+	}{
+		{"banner", func(c *Command) error {
+			out, err := c.banner(context.Background())
+			if err == nil && !strings.Contains(string(out), "Poppler") {
+				err = errors.New("no banner")
+			}
+			return err
+		}, []string{"-v"}},
+		{"listEncodings", func(c *Command) error {
+			encs, err := c.listEncodings(context.Background())
+			if want := []string{"Latin1", "UTF-8"}; err == nil && !slices.Equal(encs, want) {
+				t.Errorf("listEncodings() = %q, want %q", encs, want)
+			}
+			return err
+		}, []string{"-listencodings"}},
+		{"variant", func(c *Command) error {
+			v, err := c.variant(context.Background())
+			if err == nil && v != VariantPoppler {
+				t.Errorf("variant() = %v, want %v", v, VariantPoppler)
+			}
+			return err
+		}, []string{"-v"}},
+		{"Ping", func(c *Command) error {
+			return c.Ping(context.Background())
+		}, []string{"-v"}},
+		{"ReproInfo", func(c *Command) error {
+			info, err := c.ReproInfo(context.Background())
+			if err == nil && (info.Version != "22.02.0" || info.Banner != "pdftotext version 22.02.0") {
+				t.Errorf("ReproInfo() version = %q, banner = %q", info.Version, info.Banner)
+			}
+			return err
+		}, []string{"-v"}},
+		{"Run", func(c *Command) error {
+			_, err := c.Run(context.Background(), "in.pdf")
+			return err
+		}, []string{"in.pdf", "-"}},
+	}
+
+	for _, tt := range calls {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{respond: func(args []string) fakeResult {
+				if slices.Equal(args, []string{"-listencodings"}) {
+					// Poppler prints the list to standard error
+					return fakeResult{stderr: "Available encodings are:\nLatin1\nUTF-8\n"}
+				}
+				return fakeResult{stdout: "text\f"}
+			}}
+			cmd := newFake(t, f, WithWorkingDir(dir), WithEnv(env))
+
+			if err := tt.call(cmd); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			if len(f.calls) != 1 {
+				t.Fatalf("runs = %q, want 1", f.calls)
+			}
+			if !slices.Equal(f.calls[0], tt.args) {
+				t.Errorf("args = %q, want %q", f.calls[0], tt.args)
+			}
+			if f.dirs[0] != dir {
+				t.Errorf("dir = %q, want %q", f.dirs[0], dir)
+			}
+			if !slices.Equal(f.envs[0], env) {
+				t.Errorf("env = %q, want %q", f.envs[0], env)
+			}
+		})
+	}
+}
+
+func TestVariant(t *testing.T) {
+	tests := []struct {
+		banner string
+		want   Variant
+	}{
+		{popplerBanner, VariantPoppler},
+		{xpdfBanner, VariantXpdf},
+		{"pdftotext 1.0\n", VariantUnknown},
+	}
+
+	for _, tt := range tests {
+		cmd := newFake(t, &fakeRunner{banner: tt.banner})
+
+		got, err := cmd.variant(context.Background())
+		if err != nil {
+			t.Fatalf("variant() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("variant() of %q = %v, want %v", firstLine([]byte(tt.banner)), got, tt.want)
+		}
+	}
+}
+
+func TestPingFailure(t *testing.T) {
+	runner := RunnerFunc(func(ctx context.Context, cmd *exec.Cmd) error {
+		io.WriteString(cmd.Stderr, "error while loading shared libraries")
+		return exitStatus(127)
+	})
+
+	cmd, err := NewCommand(WithRunner(runner))
+	if err != nil {
+		t.Fatalf("NewCommand() error = %v", err)
+	}
+
+	err = cmd.Ping(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Errorf("Ping() error = %v, want failure", err)
+	}
+}
+
+func TestValidateAgainst(t *testing.T) {
+	// each executable reports its own variant, and its encodings
+	runner := RunnerFunc(func(ctx context.Context, cmd *exec.Cmd) error {
+		switch {
+		case slices.Equal(cmd.Args[1:], []string{"-v"}) && strings.Contains(cmd.Path, "xpdf"):
+			_, err := io.WriteString(cmd.Stderr, xpdfBanner)
+			return err
+		case slices.Equal(cmd.Args[1:], []string{"-v"}):
+			_, err := io.WriteString(cmd.Stderr, popplerBanner)
+			return err
+		case slices.Equal(cmd.Args[1:], []string{"-listencodings"}) && strings.Contains(cmd.Path, "xpdf"):
+			_, err := io.WriteString(cmd.Stdout, "Available encodings are:\nLatin1\n")
+			return err
+		case slices.Equal(cmd.Args[1:], []string{"-listencodings"}):
+			_, err := io.WriteString(cmd.Stdout, "Available encodings are:\nLatin1\nUTF-8\n")
+			return err
+		}
+
+		t.Errorf("unexpected run %q", cmd.Args)
+		return nil
+	})
+
+	cmd, err := NewCommand(WithRunner(runner), WithCustomPath("/usr/bin/pdftotext"),
+		WithModeLayout(), WithCropBox(), WithCropBox(), WithCropArea(0, 0, 100, 100),
+		WithValidatedEncoding(context.Background(), "UTF-8"),
+	)
+	if err != nil {
+		t.Fatalf("NewCommand() error = %v", err)
+	}
+
+	if err := cmd.ValidateAgainst(context.Background(), "/usr/local/bin/pdftotext"); err != nil {
+		t.Errorf("ValidateAgainst(Poppler) error = %v", err)
+	}
+
+	err = cmd.ValidateAgainst(context.Background(), "/opt/xpdf/pdftotext")
+	if err == nil {
+		t.Fatal("ValidateAgainst(Xpdf) error = nil, want unsupported options")
+	}
+
+	// every unsupported option is listed, once
+	want := []string{
+		"pdftotext: -cropbox is supported only by Poppler, not by Xpdf",
+		"pdftotext: -x/-y/-W/-H is supported only by Poppler, not by Xpdf",
+		`pdftotext: unknown encoding "UTF-8"`,
+	}
+	got := strings.Split(err.Error(), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("ValidateAgainst(Xpdf) error = %q, want %q", got, want)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("ValidateAgainst(Xpdf) error %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// the command itself is not changed
+	if got := cmd.Args("in.pdf")[0]; got != "/usr/bin/pdftotext" {
+		t.Errorf("path = %q, want %q", got, "/usr/bin/pdftotext")
+	}
+}
+
+func TestReproInfoVersion(t *testing.T) {
+	tests := []struct {
+		banner  string
+		version string
+		first   string
+	}{
+		{popplerBanner, "22.02.0", "pdftotext version 22.02.0"},
+		{xpdfBanner, "4.05", "pdftotext version 4.05 [www.xpdfreader.com]"},
+		{"pdftotext (custom build)\n", "", "pdftotext (custom build)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.first, func(t *testing.T) {
+			cmd := newFake(t, &fakeRunner{banner: tt.banner})
+
+			info, err := cmd.ReproInfo(context.Background())
+			if err != nil {
+				t.Fatalf("ReproInfo() error = %v", err)
+			}
+			if info.Version != tt.version || info.Banner != tt.first {
+				t.Errorf("ReproInfo() version = %q, banner = %q, want %q, %q", info.Version, info.Banner, tt.version, tt.first)
+			}
+		})
+	}
+}